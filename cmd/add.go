@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/draft/pkg/cmdhelpers"
+	"github.com/Azure/draft/pkg/config"
+	"github.com/Azure/draft/pkg/consts"
+	dryrunpkg "github.com/Azure/draft/pkg/dryrun"
+	"github.com/Azure/draft/pkg/filematches"
+	"github.com/Azure/draft/pkg/handlers"
+	"github.com/Azure/draft/pkg/prompts"
+	"github.com/Azure/draft/pkg/templatewriter"
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+// addResourceTemplates maps the resources accepted by `draft add` to their manifest template names
+var addResourceTemplates = map[string]string{
+	"hpa":                     "horizontalPodAutoscaler-manifests",
+	"horizontalpodautoscaler": "horizontalPodAutoscaler-manifests",
+	"pdb":                     "podDisruptionBudget-manifests",
+	"poddisruptionbudget":     "podDisruptionBudget-manifests",
+	"ingress":                 "ingress-manifests",
+	"service":                 "service-manifests",
+}
+
+type addCmd struct {
+	dest                     string
+	flagVariables            []string
+	templateWriter           templatewriter.TemplateWriter
+	templateVariableRecorder config.TemplateVariableRecorder
+}
+
+func newAddCmd() *cobra.Command {
+	ac := &addCmd{}
+
+	var cmd = &cobra.Command{
+		Use:   "add <resource> [flags]",
+		Short: "Adds a Kubernetes resource to an existing draft deployment",
+		Long: fmt.Sprintf(`This command generates a standalone Kubernetes resource into the helm, kustomize or manifests deployment
+previously created by draft. Supported resources: %s`, strings.Join(supportedAddResources(), ", ")),
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: supportedAddResources(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ac.run(args[0]); err != nil {
+				return err
+			}
+			log.Infof("Draft has successfully added the %s resource to your project 😃", args[0])
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&ac.dest, "destination", "d", currentDirDefaultFlagValue, "specify the path to the project directory")
+	f.StringArrayVarP(&ac.flagVariables, "variable", "", []string{}, "pass template variables (e.g. --variable MINIMUMREPLICAS=2 --variable MAXIMUMREPLICAS=5)")
+
+	ac.templateWriter = &writers.LocalFSWriter{}
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(newAddCmd())
+}
+
+func supportedAddResources() []string {
+	resources := make([]string, 0, len(addResourceTemplates))
+	for resource := range addResourceTemplates {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+func (ac *addCmd) run(resource string) error {
+	flagVariablesMap = flagVariablesToMap(ac.flagVariables)
+
+	var dryRunRecorder *dryrunpkg.DryRunRecorder
+	if dryRun {
+		dryRunRecorder = dryrunpkg.NewDryRunRecorder()
+		ac.templateVariableRecorder = dryRunRecorder
+		ac.templateWriter = dryRunRecorder
+	}
+
+	if err := ac.addResource(resource); err != nil {
+		return err
+	}
+
+	if dryRun {
+		dryRunText, err := json.MarshalIndent(dryRunRecorder.DryRunInfo, "", TWO_SPACES)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(dryRunText))
+		if dryRunFile != "" {
+			log.Printf("writing dry run info to file %s", dryRunFile)
+			if err = os.WriteFile(dryRunFile, dryRunText, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ac *addCmd) addResource(resource string) error {
+	templateName, ok := addResourceTemplates[strings.ToLower(resource)]
+	if !ok {
+		return fmt.Errorf("unsupported resource %q, must be one of: %s", resource, strings.Join(supportedAddResources(), ", "))
+	}
+
+	deployType, err := filematches.FindDraftDeploymentFiles(ac.dest)
+	if err != nil {
+		return fmt.Errorf("no draft deployment found in %s, run 'draft create' first: %w", ac.dest, err)
+	}
+	log.Debugf("found %s deployment in %s", deployType, ac.dest)
+
+	referenceValues, err := cmdhelpers.GetDeploymentReferenceValues(deployType, ac.dest)
+	if err != nil {
+		return fmt.Errorf("reading existing deployment: %w", err)
+	}
+	referenceValues["INGRESSNAME"] = referenceValues["APPNAME"]
+	referenceValues["SERVICENAME"] = referenceValues["APPNAME"]
+
+	// render into memory first so existing files are never partially overwritten
+	resourceDest := path.Join(ac.dest, consts.DeploymentFilePaths[deployType])
+	renderWriter := &writers.FileMapWriter{}
+	resourceTemplate, err := handlers.GetTemplate(templateName, "", resourceDest, renderWriter)
+	if err != nil {
+		return fmt.Errorf("getting %s template: %w", templateName, err)
+	}
+
+	for name, value := range referenceValues {
+		if _, err := resourceTemplate.Config.GetVariable(name); err == nil {
+			if err = resourceTemplate.Config.SetTypedVariable(name, value); err != nil {
+				return fmt.Errorf("setting %s from the deployment files: %w", name, err)
+			}
+		}
+	}
+	if err = resourceTemplate.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+		return err
+	}
+
+	if interactive {
+		if err = prompts.RunPromptsFromConfigWithSkips(resourceTemplate.Config); err != nil {
+			return err
+		}
+	}
+
+	if ac.templateVariableRecorder != nil {
+		for _, variable := range resourceTemplate.Config.Variables {
+			ac.templateVariableRecorder.Record(variable.Name, variable.Value)
+		}
+	}
+
+	log.Infof("--> Creating %s for %s deployment...", templateName, deployType)
+	if err = resourceTemplate.Generate(); err != nil {
+		return fmt.Errorf("generating %s: %w", templateName, err)
+	}
+
+	outputFiles := make([]string, 0, len(renderWriter.FileMap))
+	for outputFile := range renderWriter.FileMap {
+		if _, err := os.Stat(outputFile); err == nil {
+			return fmt.Errorf("file %s already exists in the %s deployment", outputFile, deployType)
+		}
+		outputFiles = append(outputFiles, outputFile)
+	}
+	sort.Strings(outputFiles)
+
+	for _, outputFile := range outputFiles {
+		if err = ac.templateWriter.WriteFile(outputFile, renderWriter.FileMap[outputFile]); err != nil {
+			return fmt.Errorf("writing %s: %w", outputFile, err)
+		}
+	}
+
+	if deployType == "kustomize" {
+		resources := make([]string, 0, len(outputFiles))
+		for _, outputFile := range outputFiles {
+			relPath, err := filepath.Rel(resourceDest, outputFile)
+			if err != nil {
+				return fmt.Errorf("getting kustomize resource path: %w", err)
+			}
+			resources = append(resources, filepath.ToSlash(relPath))
+		}
+
+		kustomizationPath := filepath.Join(resourceDest, "kustomization.yaml")
+		if err = cmdhelpers.AddKustomizeResources(kustomizationPath, resources, ac.templateWriter); err != nil {
+			return fmt.Errorf("updating %s: %w", kustomizationPath, err)
+		}
+	}
+
+	return nil
+}
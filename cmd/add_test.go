@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/config"
+	"github.com/Azure/draft/pkg/osutil"
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+func TestAddResource(t *testing.T) {
+	oldInteractive := interactive
+	interactive = false
+	defer func() { interactive = oldInteractive }()
+
+	expectedFiles := map[string]string{
+		"helm":      "charts/templates/hpa.yaml",
+		"kustomize": "overlays/production/hpa.yaml",
+		"manifests": "manifests/hpa.yaml",
+	}
+
+	for deployType, expectedFile := range expectedFiles {
+		testDir := t.TempDir()
+		err := osutil.CopyDir(os.DirFS("../test/templates"), deployType, testDir, &config.DraftConfig{}, &writers.LocalFSWriter{})
+		assert.Nil(t, err)
+
+		ac := &addCmd{dest: testDir, templateWriter: &writers.LocalFSWriter{}}
+		err = ac.addResource("hpa")
+		assert.Nil(t, err)
+
+		hpa, err := os.ReadFile(filepath.Join(testDir, expectedFile))
+		assert.Nil(t, err)
+		assert.Contains(t, string(hpa), "name: test")
+
+		if deployType == "kustomize" {
+			kustomization, err := os.ReadFile(filepath.Join(testDir, "overlays/production/kustomization.yaml"))
+			assert.Nil(t, err)
+			assert.Contains(t, string(kustomization), "- hpa.yaml")
+		}
+
+		// adding the same resource twice must not overwrite the existing file
+		err = ac.addResource("hpa")
+		assert.NotNil(t, err)
+	}
+
+	ac := &addCmd{dest: t.TempDir(), templateWriter: &writers.FileMapWriter{}}
+	assert.NotNil(t, ac.addResource("hpa"))
+	assert.NotNil(t, ac.addResource("configmap"))
+}
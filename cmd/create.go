@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/manifoldco/promptui"
@@ -23,7 +24,6 @@ import (
 	"github.com/Azure/draft/pkg/reporeader/readers"
 	"github.com/Azure/draft/pkg/templatewriter"
 	"github.com/Azure/draft/pkg/templatewriter/writers"
-	"github.com/Azure/draft/template"
 )
 
 // ErrNoLanguageDetected is raised when `draft create` does not detect source
@@ -38,19 +38,19 @@ const TWO_SPACES = "  "
 const emptyDefaultFlagValue = ""
 const currentDirDefaultFlagValue = "."
 
-const DOCKERFILES_DIR = "dockerfiles"
+const defaultSaveConfigFileName = "draft.yaml"
 
 func listSupportedLanguages() ([]string, error) {
 	var supportedLanguages []string
-	entries, err := template.Templates.ReadDir(DOCKERFILES_DIR)
-	if err != nil {
-		return supportedLanguages, fmt.Errorf("reading supported languages from embedded fs: %w", err)
-	}
-	for _, d := range entries {
-		if d.IsDir() {
-			supportedLanguages = append(supportedLanguages, d.Name())
+	for name := range handlers.GetTemplatesByType(handlers.TemplateTypeDockerfile) {
+		if lang, ok := strings.CutPrefix(name, "dockerfile-"); ok {
+			supportedLanguages = append(supportedLanguages, lang)
 		}
 	}
+	if len(supportedLanguages) == 0 {
+		return nil, errors.New("no dockerfile templates found")
+	}
+	slices.Sort(supportedLanguages)
 	return supportedLanguages, nil
 }
 
@@ -62,10 +62,15 @@ type createCmd struct {
 	dockerfileOnly    bool
 	deploymentOnly    bool
 	skipFileDetection bool
+	mergeStrategy     string
+	validateMode      string
 	flagVariables     []string
 
 	createConfigPath string
 	createConfig     *CreateConfig
+	saveConfigPath   string
+	// savedConfig records the inputs effectively used by this run so it can be replayed with --create-config
+	savedConfig *CreateConfig
 
 	templateWriter           templatewriter.TemplateWriter
 	templateVariableRecorder config.TemplateVariableRecorder
@@ -90,13 +95,17 @@ func newCreateCmd() *cobra.Command {
 	f := cmd.Flags()
 
 	f.StringVarP(&cc.createConfigPath, "create-config", "c", emptyDefaultFlagValue, "specify the path to the configuration file")
+	f.StringVarP(&cc.saveConfigPath, "save-config", "", emptyDefaultFlagValue, fmt.Sprintf("specify the path to save the configuration used by this run to (defaults to %s in the project directory for interactive runs)", defaultSaveConfigFileName))
 	f.StringVarP(&cc.lang, "language", "l", emptyDefaultFlagValue, "specify the language used to create the Kubernetes deployment")
 	f.StringVarP(&cc.dest, "destination", "d", currentDirDefaultFlagValue, "specify the path to the project directory")
 	f.StringVarP(&cc.deployType, "deploy-type", "", emptyDefaultFlagValue, "specify deployment type (eg. helm, kustomize, manifests)")
 	f.BoolVar(&cc.dockerfileOnly, "dockerfile-only", false, "only create Dockerfile in the project directory")
 	f.BoolVar(&cc.deploymentOnly, "deployment-only", false, "only create deployment files in the project directory")
 	f.BoolVar(&cc.skipFileDetection, "skip-file-detection", false, "skip file detection step")
+	f.StringVar(&cc.mergeStrategy, "merge", emptyDefaultFlagValue, "three-way merge regenerated files with local edits, writing conflict markers or refusing on conflicts (markers, refuse)")
+	f.Lookup("merge").NoOptDefVal = string(writers.MergeStrategyMarkers)
 	f.StringArrayVarP(&cc.flagVariables, "variable", "", []string{}, "pass template variables (e.g. --variable PORT=8080 --variable APPNAME=test)")
+	addValidateFlag(cmd, &cc.validateMode)
 
 	return cmd
 }
@@ -117,23 +126,86 @@ func (cc *createCmd) initConfig() error {
 		return nil
 	}
 
-	//TODO: create a config for the user and save it for subsequent uses
 	cc.createConfig = &CreateConfig{}
 
 	return nil
 }
 
+// getSaveConfigPath returns the path the effective create config should be saved to, or an empty string if it should not be saved
+func (cc *createCmd) getSaveConfigPath() string {
+	if cc.saveConfigPath != "" {
+		return cc.saveConfigPath
+	}
+
+	// only interactive runs produce answers that are not already captured in a config file
+	if interactive && cc.createConfigPath == "" {
+		return filepath.Join(cc.dest, defaultSaveConfigFileName)
+	}
+
+	return ""
+}
+
+// saveConfig writes the create config effectively used by this run so it can be rerun with --create-config and --interactive=false
+func (cc *createCmd) saveConfig() error {
+	saveConfigPath := cc.getSaveConfigPath()
+	if saveConfigPath == "" || cc.savedConfig == nil {
+		return nil
+	}
+	if cc.savedConfig.LanguageType == "" && cc.savedConfig.DeployType == "" {
+		log.Debug("no files were generated, skipping saving create config")
+		return nil
+	}
+
+	configBytes, err := yaml.Marshal(cc.savedConfig)
+	if err != nil {
+		return fmt.Errorf("marshalling create config: %w", err)
+	}
+
+	if err = os.WriteFile(saveConfigPath, configBytes, 0644); err != nil {
+		return fmt.Errorf("writing create config: %w", err)
+	}
+
+	log.Infof("--> Saved create config to %s, rerun with 'draft create --create-config %s --interactive=false'", saveConfigPath, saveConfigPath)
+	return nil
+}
+
+// toUserInputs converts the set variables of a draft config into create config inputs
+func toUserInputs(draftConfig *config.DraftConfig) []UserInputs {
+	inputs := make([]UserInputs, 0, len(draftConfig.Variables))
+	for _, variable := range draftConfig.Variables {
+		if variable.Value == "" {
+			continue
+		}
+		inputs = append(inputs, UserInputs{Name: variable.Name, Value: variable.Value})
+	}
+	return inputs
+}
+
 func (cc *createCmd) run() error {
 	log.Debugf("config: %s", cc.createConfigPath)
 	log.Debugf("interactive: %t", interactive)
 
 	flagVariablesMap = flagVariablesToMap(cc.flagVariables)
 
+	mergeStrategy := writers.MergeStrategy(cc.mergeStrategy)
+	switch mergeStrategy {
+	case writers.MergeStrategyNone, writers.MergeStrategyMarkers, writers.MergeStrategyRefuse:
+	default:
+		return fmt.Errorf("invalid merge strategy %q, must be one of: %s, %s", cc.mergeStrategy, writers.MergeStrategyMarkers, writers.MergeStrategyRefuse)
+	}
+	if err := checkValidateMode(cc.validateMode); err != nil {
+		return err
+	}
+
 	var dryRunRecorder *dryrunpkg.DryRunRecorder
+	var mergeWriter *writers.MergeWriter
 	if dryRun {
 		dryRunRecorder = dryrunpkg.NewDryRunRecorder()
 		cc.templateVariableRecorder = dryRunRecorder
 		cc.templateWriter = dryRunRecorder
+	} else if mergeStrategy != writers.MergeStrategyNone {
+		mergeWriter = &writers.MergeWriter{Root: cc.dest, Strategy: mergeStrategy}
+		cc.templateWriter = mergeWriter
 	} else {
 		cc.templateWriter = &writers.LocalFSWriter{}
 	}
@@ -143,8 +215,16 @@ func (cc *createCmd) run() error {
 	if err != nil {
 		return err
 	}
+	cc.savedConfig = &CreateConfig{}
 
 	err = cc.createFiles(detectedLangDraftConfig, languageName)
+	// merged files are written once every file merged, so a refused merge writes nothing
+	if err == nil && mergeWriter != nil {
+		err = mergeWriter.Commit()
+	}
+	if err == nil && !dryRun {
+		err = cc.saveConfig()
+	}
 	if dryRun {
 		cc.templateVariableRecorder.Record(LANGUAGE_VARIABLE, languageName)
 		dryRunText, err := json.MarshalIndent(dryRunRecorder.DryRunInfo, "", TWO_SPACES)
@@ -160,6 +240,13 @@ func (cc *createCmd) run() error {
 			}
 		}
 	}
+	if err == nil && mergeWriter != nil && len(mergeWriter.Conflicts) > 0 {
+		return fmt.Errorf("merge conflicts written to %s, resolve them before committing", strings.Join(mergeWriter.Conflicts, ", "))
+	}
+	// only a deployment this run generated is validated
+	if err == nil && cc.savedConfig.DeployType != "" {
+		err = validateGeneratedDeployment(cc.dest, cc.savedConfig.DeployType, cc.validateMode)
+	}
 	return err
 }
 
@@ -315,7 +402,9 @@ func (cc *createCmd) generateDockerfile(dockerfileTemplate *handlers.Template, l
 			return err
 		}
 	} else {
-		dockerfileTemplate.Config.VariableMapToDraftConfig(flagVariablesMap)
+		if err = dockerfileTemplate.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+			return err
+		}
 
 		if err = prompts.RunPromptsFromConfigWithSkips(dockerfileTemplate.Config); err != nil {
 			return err
@@ -332,6 +421,11 @@ func (cc *createCmd) generateDockerfile(dockerfileTemplate *handlers.Template, l
 		return fmt.Errorf("there was an error when creating the Dockerfile for language %s: %w", cc.createConfig.LanguageType, err)
 	}
 
+	if cc.savedConfig != nil {
+		cc.savedConfig.LanguageType = lowerLang
+		cc.savedConfig.LanguageVariables = toUserInputs(dockerfileTemplate.Config)
+	}
+
 	log.Info("--> Creating Dockerfile...\n")
 	return nil
 }
@@ -358,7 +452,9 @@ func (cc *createCmd) generateDeployment() error {
 		if deployTemplate == nil || deployTemplate.Config == nil {
 			return errors.New("invalid deployment type")
 		}
-		deployTemplate.Config.VariableMapToDraftConfig(flagVariablesMap)
+		if err = deployTemplate.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+			return err
+		}
 		if !interactive {
 			currentDir, err := os.Getwd()
 			if err != nil {
@@ -411,7 +507,9 @@ func (cc *createCmd) generateDeployment() error {
 			return errors.New("invalid deployment type")
 		}
 
-		deployTemplate.Config.VariableMapToDraftConfig(flagVariablesMap)
+		if err = deployTemplate.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+			return err
+		}
 
 		err = prompts.RunPromptsFromConfigWithSkips(deployTemplate.Config)
 		if err != nil {
@@ -426,7 +524,16 @@ func (cc *createCmd) generateDeployment() error {
 	}
 
 	log.Infof("--> Creating %s Kubernetes resources...\n", deployType)
-	return deployTemplate.Generate()
+	if err = deployTemplate.Generate(); err != nil {
+		return err
+	}
+
+	if cc.savedConfig != nil {
+		cc.savedConfig.DeployType = deployType
+		cc.savedConfig.DeployVariables = toUserInputs(deployTemplate.Config)
+	}
+
+	return nil
 }
 
 func (cc *createCmd) createFiles(detectedLangTempalte *handlers.Template, lowerLang string) error {
@@ -436,7 +543,8 @@ func (cc *createCmd) createFiles(detectedLangTempalte *handlers.Template, lowerL
 		return errors.New("can only pass in one of --dockerfile-only and --deployment-only")
 	}
 
-	if cc.skipFileDetection {
+	// merging regenerates every file on top of the existing ones, so there is nothing to detect
+	if cc.skipFileDetection || cc.mergeStrategy != "" {
 		if !cc.deploymentOnly {
 			err := cc.generateDockerfile(detectedLangTempalte, lowerLang)
 			if err != nil {
@@ -529,7 +637,9 @@ func init() {
 func validateConfigInputsToPrompts(draftConfig *config.DraftConfig, provided []UserInputs) error {
 	// set inputs to provided values
 	for _, providedVar := range provided {
-		draftConfig.SetVariable(providedVar.Name, providedVar.Value)
+		if err := draftConfig.SetTypedVariable(providedVar.Name, providedVar.Value); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -120,6 +120,33 @@ func TestRunCreateDockerfileWithRepoReader(t *testing.T) {
 	}
 }
 
+func TestSaveConfig(t *testing.T) {
+	testDir := t.TempDir()
+	flagVariablesMap = map[string]string{"PORT": "8080", "APPNAME": "testingsaveconfig", "VERSION": "1.18", "SERVICEPORT": "8080", "NAMESPACE": "testNamespace", "IMAGENAME": "testImage", "IMAGETAG": "latest", "DOCKERFILENAME": "Dockerfile"}
+	mockCC := createCmd{
+		dest:           testDir,
+		lang:           "go",
+		deployType:     "manifests",
+		createConfig:   &CreateConfig{},
+		saveConfigPath: filepath.Join(testDir, "saved.yaml"),
+		savedConfig:    &CreateConfig{},
+		templateWriter: &writers.LocalFSWriter{},
+	}
+
+	detectedLang, lowerLang, err := mockCC.mockDetectLanguage()
+	assert.Nil(t, err)
+	assert.Nil(t, mockCC.generateDockerfile(detectedLang, lowerLang))
+	assert.Nil(t, mockCC.generateDeployment())
+	assert.Nil(t, mockCC.saveConfig())
+
+	replayCC := createCmd{createConfigPath: mockCC.saveConfigPath}
+	assert.Nil(t, replayCC.initConfig())
+	assert.Equal(t, "go", replayCC.createConfig.LanguageType)
+	assert.Equal(t, "manifests", replayCC.createConfig.DeployType)
+	assert.Contains(t, replayCC.createConfig.LanguageVariables, UserInputs{Name: "PORT", Value: "8080"})
+	assert.Contains(t, replayCC.createConfig.DeployVariables, UserInputs{Name: "APPNAME", Value: "testingsaveconfig"})
+}
+
 func TestInitConfig(t *testing.T) {
 	mockCC := &createCmd{}
 	mockCC.createConfig = &CreateConfig{}
@@ -273,3 +300,44 @@ func getAllDeploymentFiles(src string) ([]string, error) {
 		})
 	return deploymentFiles, err
 }
+
+func TestLoadTemplateDirs(t *testing.T) {
+	t.Cleanup(func() {
+		templateDirs = nil
+		assert.Nil(t, handlers.LoadTemplateDirs())
+	})
+
+	flagDir := t.TempDir()
+	envDir := t.TempDir()
+	writeLanguageTemplate := func(dir, language string) {
+		langDir := filepath.Join(dir, language)
+		assert.Nil(t, os.MkdirAll(langDir, 0755))
+		draftYaml := fmt.Sprintf("templateName: \"dockerfile-%s\"\ntype: \"dockerfile\"\nversions: [\"0.0.1\"]\ndefaultVersion: \"0.0.1\"\n", language)
+		assert.Nil(t, os.WriteFile(filepath.Join(langDir, "draft.yaml"), []byte(draftYaml), 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(langDir, "Dockerfile"), []byte("FROM "+language+"\n"), 0644))
+	}
+	writeLanguageTemplate(flagDir, "go")
+	writeLanguageTemplate(envDir, "go")
+	writeLanguageTemplate(envDir, "mylang")
+
+	templateDirs = []string{flagDir}
+	t.Setenv(templatePathEnv, envDir)
+	assert.Nil(t, loadTemplateDirs(true))
+
+	// --template-dir directories take precedence over DRAFT_TEMPLATE_PATH
+	assert.Equal(t, flagDir, handlers.GetTemplates()["dockerfile-go"].Origin())
+	assert.Equal(t, envDir, handlers.GetTemplates()["dockerfile-mylang"].Origin())
+
+	languages, err := listSupportedLanguages()
+	assert.Nil(t, err)
+	assert.Contains(t, languages, "mylang")
+	assert.Contains(t, languages, "python")
+
+	ic := &infoCmd{}
+	assert.Nil(t, ic.run())
+	assert.Contains(t, ic.info.Templates, templateInfo{Name: "dockerfile-mylang", Type: "dockerfile", Origin: envDir})
+	assert.Contains(t, ic.info.Templates, templateInfo{Name: "deployment-helm", Type: "deployment", Origin: handlers.EmbeddedTemplateOrigin})
+
+	t.Setenv(templatePathEnv, filepath.Join(envDir, "missing"))
+	assert.NotNil(t, loadTemplateDirs(true))
+}
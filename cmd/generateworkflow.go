@@ -15,10 +15,28 @@ import (
 	"github.com/Azure/draft/pkg/templatewriter/writers"
 )
 
+const (
+	ciGitHub         = "github"
+	ciAzurePipelines = "azure-pipelines"
+)
+
+type ciSystem struct {
+	templatePrefix string
+	displayName    string
+}
+
+// ciSystems maps each supported CI system to its workflow template name prefix
+var ciSystems = map[string]ciSystem{
+	ciGitHub:         {templatePrefix: "github-workflow", displayName: "Github workflow"},
+	ciAzurePipelines: {templatePrefix: "azure-pipeline", displayName: "Azure Pipeline"},
+}
+
 type generateWorkflowCmd struct {
 	dest           string
 	deployType     string
+	ci             string
 	flagVariables  []string
+	validateMode   string
 	templateWriter templatewriter.TemplateWriter
 }
 
@@ -28,16 +46,21 @@ func newGenerateWorkflowCmd() *cobra.Command {
 	gwCmd.dest = ""
 	var cmd = &cobra.Command{
 		Use:   "generate-workflow [flags]",
-		Short: "Generates a Github workflow for automatic build and deploy to AKS",
-		Long: `This command will generate a Github workflow to build and deploy an application containerized 
-with draft on AKS. This command assumes the 'setup-gh' command has been run properly.`,
+		Short: "Generates a Github workflow or Azure Pipeline for automatic build and deploy to AKS",
+		Long: `This command will generate a Github workflow or an Azure Pipeline to build and deploy an application containerized 
+with draft on AKS. For Github workflows, this command assumes the 'setup-gh' command has been run properly.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			log.Info("--> Generating Github workflow")
+			ci, ok := ciSystems[gwCmd.ci]
+			if !ok {
+				return fmt.Errorf("unsupported ci system %q, must be one of: %s, %s", gwCmd.ci, ciGitHub, ciAzurePipelines)
+			}
+
+			log.Infof("--> Generating %s", ci.displayName)
 			if err := gwCmd.generateWorkflows(); err != nil {
 				return err
 			}
 
-			log.Info("Draft has successfully generated a Github workflow for your project 😃")
+			log.Infof("Draft has successfully generated a %s for your project 😃", ci.displayName)
 
 			return nil
 		},
@@ -47,7 +70,9 @@ with draft on AKS. This command assumes the 'setup-gh' command has been run prop
 
 	f.StringVarP(&gwCmd.dest, "destination", "d", currentDirDefaultFlagValue, "specify the path to the project directory")
 	f.StringVarP(&gwCmd.deployType, "deploy-type", "", "", "specify the k8s deployment type (helm, kustomize, manifests)")
+	f.StringVarP(&gwCmd.ci, "ci", "", ciGitHub, "specify the ci system to generate the workflow for (github, azure-pipelines)")
 	f.StringArrayVarP(&gwCmd.flagVariables, "variable", "", []string{}, "pass template variables (e.g. --variable CLUSTERNAME=testCluster --variable DOCKERFILE=./Dockerfile)")
+	addValidateFlag(cmd, &gwCmd.validateMode)
 	gwCmd.templateWriter = &writers.LocalFSWriter{}
 	return cmd
 }
@@ -60,6 +85,9 @@ func (gwc *generateWorkflowCmd) generateWorkflows() error {
 	var err error
 
 	flagVariablesMap = flagVariablesToMap(gwc.flagVariables)
+	if err = checkValidateMode(gwc.validateMode); err != nil {
+		return err
+	}
 
 	if gwc.deployType == "" {
 		selection := &promptui.Select{
@@ -73,7 +101,12 @@ func (gwc *generateWorkflowCmd) generateWorkflows() error {
 		}
 	}
 
-	t, err := handlers.GetTemplate(fmt.Sprintf("github-workflow-%s", gwc.deployType), "", gwc.dest, gwc.templateWriter)
+	ci, ok := ciSystems[gwc.ci]
+	if !ok {
+		return fmt.Errorf("unsupported ci system: %s", gwc.ci)
+	}
+
+	t, err := handlers.GetTemplate(fmt.Sprintf("%s-%s", ci.templatePrefix, gwc.deployType), "", gwc.dest, gwc.templateWriter)
 	if err != nil {
 		return fmt.Errorf("failed to get template: %e", err)
 	}
@@ -81,7 +114,9 @@ func (gwc *generateWorkflowCmd) generateWorkflows() error {
 		return fmt.Errorf("template is nil")
 	}
 
-	t.Config.VariableMapToDraftConfig(flagVariablesMap)
+	if err = t.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+		return err
+	}
 
 	if err = prompts.RunPromptsFromConfigWithSkips(t.Config); err != nil {
 		return err
@@ -91,7 +126,12 @@ func (gwc *generateWorkflowCmd) generateWorkflows() error {
 		return fmt.Errorf("update production deployments: %w", err)
 	}
 
-	return t.Generate()
+	if err = t.Generate(); err != nil {
+		return err
+	}
+
+	// the production deployments were updated with the image the workflow pushes
+	return validateGeneratedDeployment(gwc.dest, gwc.deployType, gwc.validateMode)
 }
 
 func flagVariablesToMap(flagVariables []string) map[string]string {
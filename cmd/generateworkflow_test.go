@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/config"
+	"github.com/Azure/draft/pkg/osutil"
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+func TestGenerateWorkflowsAzurePipelines(t *testing.T) {
+	for _, deployType := range []string{"helm", "kustomize", "manifests"} {
+		testDir := t.TempDir()
+		err := osutil.CopyDir(os.DirFS("../test/templates"), deployType, testDir, &config.DraftConfig{}, &writers.LocalFSWriter{})
+		assert.Nil(t, err)
+
+		gwc := &generateWorkflowCmd{
+			dest:       testDir,
+			deployType: deployType,
+			ci:         ciAzurePipelines,
+			flagVariables: []string{
+				"ARMSERVICECONNECTION=testserviceconnection",
+				"AZURECONTAINERREGISTRY=myacr",
+				"CONTAINERNAME=myapp",
+				"CLUSTERRESOURCEGROUP=myrg",
+				"ACRRESOURCEGROUP=myrg",
+				"CLUSTERNAME=testcluster",
+				"PIPELINENAME=testpipeline",
+				"BRANCHNAME=main",
+				"NAMESPACE=default",
+				"BUILDCONTEXTPATH=.",
+				"DOCKERFILE=./Dockerfile",
+				"CLUSTERRESOURCETYPE=Microsoft.ContainerService/managedClusters",
+			},
+			templateWriter: &writers.LocalFSWriter{},
+		}
+
+		err = gwc.generateWorkflows()
+		assert.Nil(t, err)
+
+		pipeline, err := os.ReadFile(filepath.Join(testDir, ".pipelines/azure-kubernetes-service.yaml"))
+		assert.Nil(t, err)
+		assert.Contains(t, string(pipeline), "azureContainerRegistry: myacr")
+
+		if deployType == "manifests" {
+			deployment, err := os.ReadFile(filepath.Join(testDir, "manifests/deployment.yaml"))
+			assert.Nil(t, err)
+			assert.Contains(t, string(deployment), "image: myacr.azurecr.io/myapp")
+		}
+	}
+
+	gwc := &generateWorkflowCmd{dest: t.TempDir(), deployType: "manifests", ci: "jenkins"}
+	assert.NotNil(t, gwc.generateWorkflows())
+}
@@ -3,11 +3,14 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/Azure/draft/pkg/handlers"
+	"github.com/Azure/draft/pkg/safeguards"
 )
 
 type Format string
@@ -32,11 +35,21 @@ type draftConfigInfo struct {
 	Name                  string              `json:"name"`
 	DisplayName           string              `json:"displayName,omitempty"`
 	VariableExampleValues map[string][]string `json:"variableExampleValues,omitempty"`
+	Origin                string              `json:"origin"`
+}
+
+// templateInfo describes a loaded template and where it was loaded from, embedded or a template directory
+type templateInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Origin string `json:"origin"`
 }
 
 type draftInfo struct {
-	SupportedLanguages       []draftConfigInfo `json:"supportedLanguages"`
-	SupportedDeploymentTypes [3]string         `json:"supportedDeploymentTypes"`
+	SupportedLanguages          []draftConfigInfo `json:"supportedLanguages"`
+	SupportedDeploymentTypes    [3]string         `json:"supportedDeploymentTypes"`
+	SupportedSafeguardsVersions []string          `json:"supportedSafeguardsVersions"`
+	Templates                   []templateInfo    `json:"templates"`
 }
 
 func newInfoCmd() *cobra.Command {
@@ -68,13 +81,32 @@ func (ic *infoCmd) run() error {
 			Name:                  template.Config.TemplateName,
 			DisplayName:           template.Config.DisplayName,
 			VariableExampleValues: template.Config.GetVariableExampleValues(),
+			Origin:                template.Origin(),
 		}
 		languagesInfo = append(languagesInfo, newConfig)
 	}
 
+	templatesInfo := make([]templateInfo, 0)
+	for _, template := range handlers.GetTemplates() {
+		templatesInfo = append(templatesInfo, templateInfo{
+			Name:   template.Config.TemplateName,
+			Type:   template.Config.Type,
+			Origin: template.Origin(),
+		})
+	}
+	slices.SortFunc(templatesInfo, func(a, b templateInfo) int { return strings.Compare(a.Name, b.Name) })
+
+	log.Debugf("getting supported safeguards versions")
+	safeguardsVersions, err := safeguards.SupportedVersions()
+	if err != nil {
+		return err
+	}
+
 	ic.info = &draftInfo{
-		SupportedLanguages:       languagesInfo,
-		SupportedDeploymentTypes: supportedDeploymentTypes,
+		SupportedLanguages:          languagesInfo,
+		SupportedDeploymentTypes:    supportedDeploymentTypes,
+		SupportedSafeguardsVersions: safeguardsVersions,
+		Templates:                   templatesInfo,
 	}
 
 	infoText, err := json.MarshalIndent(ic.info, "", "  ")
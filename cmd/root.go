@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
+
 	cc "github.com/ivanpirog/coloredcobra"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/Azure/draft/pkg/handlers"
 	"github.com/Azure/draft/pkg/logger"
 )
 
+// templatePathEnv lists template directories, separated like PATH, searched after the ones given with --template-dir
+const templatePathEnv = "DRAFT_TEMPLATE_PATH"
+
 var cfgFile string
 var verbose bool
 var provider string
@@ -15,6 +23,7 @@ var silent bool
 var dryRun bool
 var dryRunFile string
 var interactive bool
+var templateDirs []string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -30,7 +39,7 @@ This will prompt you to create a Dockerfile and deployment files for your projec
 
 For more information, please visit the Draft Github page: https://github.com/Azure/draft.`,
 
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			logrus.SetLevel(logrus.DebugLevel)
 		} else if silent {
@@ -39,6 +48,8 @@ For more information, please visit the Draft Github page: https://github.com/Azu
 		}
 		logrus.SetOutput(&logger.OutputSplitter{})
 		logrus.SetFormatter(new(logger.CustomFormatter))
+		// the template subcommands manage the packs, so a broken pack must not keep them from running
+		return loadTemplateDirs(!isTemplateCommand(cmd))
 	},
 	SilenceErrors: true,
 }
@@ -64,5 +75,51 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&silent, "silent", "", false, "enable silent logging")
 	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "", false, "enable dry run mode in which no files are written to disk")
 	rootCmd.PersistentFlags().BoolVarP(&interactive, "interactive", "", true, "toggle interactive prompting for user input (default is true, use --interactive=false to disable)")
+	rootCmd.PersistentFlags().StringArrayVar(&templateDirs, "template-dir", []string{}, "load additional draft.yaml based templates from a directory, shadowing built-in templates with the same templateName, can be repeated (also read from "+templatePathEnv+")")
 	rootCmd.PersistentFlags().StringVar(&dryRunFile, "dry-run-file", "", "optional file to write dry run summary in json format into (requires --dry-run flag)")
 }
+
+// loadTemplateDirs adds the templates of the --template-dir directories, then the DRAFT_TEMPLATE_PATH ones and then the
+// installed template packs, when asked for, to the embedded templates, earlier directories taking precedence. Packs that
+// fail to load are skipped with a warning.
+func loadTemplateDirs(withPacks bool) error {
+	dirs := slices.Clone(templateDirs)
+	for _, dir := range filepath.SplitList(os.Getenv(templatePathEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	var packDirs []string
+	if withPacks {
+		packDirs = installedTemplatePackDirs()
+	}
+	if len(dirs)+len(packDirs) == 0 {
+		return nil
+	}
+	logrus.Debugf("loading templates from %v", append(dirs, packDirs...))
+	err := handlers.LoadTemplateDirs(append(dirs, packDirs...)...)
+	if err == nil || len(packDirs) == 0 {
+		return err
+	}
+
+	// only look for the broken packs once loading failed, keeping the common case to a single load
+	validPackDirs := make([]string, 0, len(packDirs))
+	for _, dir := range packDirs {
+		if _, err := handlers.ReadTemplateDir(dir); err != nil {
+			logrus.Warnf("skipping template pack %s: %s", filepath.Base(dir), err.Error())
+			continue
+		}
+		validPackDirs = append(validPackDirs, dir)
+	}
+	return handlers.LoadTemplateDirs(append(dirs, validPackDirs...)...)
+}
+
+// isTemplateCommand reports whether cmd is the template command or one of its subcommands
+func isTemplateCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "template" && c.HasParent() && !c.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/draft/pkg/handlers"
+	"github.com/Azure/draft/pkg/templatepacks"
+)
+
+type templateCmd struct {
+	storeDir string
+	name     string
+	checksum string
+	force    bool
+	version  string
+}
+
+func newTemplateCmd() *cobra.Command {
+	tc := &templateCmd{}
+
+	var cmd = &cobra.Command{
+		Use:   "template",
+		Short: "Manages the template packs draft generates files from",
+		Long: `This command manages template packs, sets of draft.yaml based templates with the directory layout of draft's
+built-in templates, fetched from a git repository or an OCI artifact into ~/.draft/templates. The templates of
+installed packs are available to every draft command and shadow the built-in templates with the same templateName.`,
+	}
+
+	var addCmd = &cobra.Command{
+		Use:   "add <source> [flags]",
+		Short: "Fetches a template pack from a git repository or an OCI artifact",
+		Long: fmt.Sprintf(`This command fetches a template pack and installs it after verifying its templates and checksum.
+Sources can be
+  - a git repository URL, optionally followed by #<branch or tag>, e.g. https://github.com/contoso/templates.git#v1
+  - an OCI artifact in a registry, e.g. oci://contoso.azurecr.io/draft/templates:v1
+  - an OCI artifact in a local OCI image layout directory, e.g. oci-layout://./layout:v1
+OCI artifacts hold the pack as a gzipped tar archive in a layer of media type %s.`, templatepacks.PackMediaType),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tc.add(args[0])
+		},
+	}
+	f := addCmd.Flags()
+	f.StringVar(&tc.name, "name", "", "specify the name of the template pack, defaults to the last element of the source")
+	f.StringVar(&tc.checksum, "checksum", "", "specify the expected h1: checksum of the template pack, as printed when the pack was added")
+	f.BoolVar(&tc.force, "force", false, "replace an installed template pack with the same name")
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists the installed template packs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tc.list(cmd.OutOrStdout())
+		},
+	}
+
+	var removeCmd = &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Removes an installed template pack",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tc.remove(args[0])
+		},
+	}
+
+	var schemaCmd = &cobra.Command{
+		Use:   "schema <templateName> [flags]",
+		Short: "Prints a JSON Schema of the variables of a template",
+		Long: `This command prints a JSON Schema describing the variables a template accepts, with their types, kinds,
+descriptions, allowed, example and default values, the activeWhen constraints as if/then conditions and the version
+ranges, so forms can be rendered and input validated before draft is run.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// unlike the other template subcommands, schema describes the templates of the installed packs too
+			if err := loadTemplateDirs(true); err != nil {
+				return err
+			}
+			return tc.schema(cmd.OutOrStdout(), args[0])
+		},
+	}
+	schemaCmd.Flags().StringVar(&tc.version, "version", "", "only describe the variables of a template version, defaults to every version")
+
+	cmd.AddCommand(addCmd, listCmd, removeCmd, schemaCmd)
+	return cmd
+}
+
+func (tc *templateCmd) store() (*templatepacks.Store, error) {
+	if tc.storeDir == "" {
+		dir, err := templatepacks.DefaultStoreDir()
+		if err != nil {
+			return nil, err
+		}
+		tc.storeDir = dir
+	}
+	return templatepacks.NewStore(tc.storeDir), nil
+}
+
+func (tc *templateCmd) add(source string) error {
+	store, err := tc.store()
+	if err != nil {
+		return err
+	}
+	pack, err := store.Add(context.Background(), source, templatepacks.AddOptions{Name: tc.name, Checksum: tc.checksum, Force: tc.force})
+	if err != nil {
+		return err
+	}
+	log.Infof("Added template pack %s with templates %s", pack.Name, strings.Join(pack.Templates, ", "))
+	log.Infof("checksum: %s", pack.Checksum)
+	return nil
+}
+
+func (tc *templateCmd) list(out io.Writer) error {
+	store, err := tc.store()
+	if err != nil {
+		return err
+	}
+	packs, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tTEMPLATES\tCHECKSUM")
+	for _, p := range packs {
+		checksum := p.Checksum
+		if err = store.Verify(p); err != nil {
+			checksum += " (modified)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Source, strings.Join(p.Templates, ","), checksum)
+	}
+	return w.Flush()
+}
+
+func (tc *templateCmd) remove(name string) error {
+	store, err := tc.store()
+	if err != nil {
+		return err
+	}
+	if err = store.Remove(name); err != nil {
+		return err
+	}
+	log.Infof("Removed template pack %s", name)
+	return nil
+}
+
+func (tc *templateCmd) schema(out io.Writer, templateName string) error {
+	template, ok := handlers.GetTemplates()[strings.ToLower(templateName)]
+	if !ok {
+		return fmt.Errorf("template not found: %s", templateName)
+	}
+	if template.Config.Abstract {
+		return fmt.Errorf("template %s is abstract and can only be extended", templateName)
+	}
+	schema, err := template.Config.JSONSchema(tc.version)
+	if err != nil {
+		return fmt.Errorf("generating schema of template %s: %w", templateName, err)
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// installedTemplatePackDirs returns the directories of the template packs installed in the default store
+func installedTemplatePackDirs() []string {
+	dir, err := templatepacks.DefaultStoreDir()
+	if err != nil {
+		log.Debugf("not loading template packs: %s", err.Error())
+		return nil
+	}
+	if _, err = os.Stat(dir); err != nil {
+		return nil
+	}
+	dirs, err := templatepacks.NewStore(dir).Dirs()
+	if err != nil {
+		log.Warnf("not loading template packs: %s", err.Error())
+		return nil
+	}
+	return dirs
+}
+
+func init() {
+	rootCmd.AddCommand(newTemplateCmd())
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/Azure/draft/pkg/handlers"
+)
+
+func TestTemplatePackCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Cleanup(func() { assert.Nil(t, handlers.LoadTemplateDirs()) })
+
+	repo := filepath.Join(t.TempDir(), "golden")
+	langDir := filepath.Join(repo, "dockerfiles", "golden")
+	assert.Nil(t, os.MkdirAll(langDir, 0755))
+	draftYaml := "templateName: \"dockerfile-golden\"\ntype: \"dockerfile\"\nversions: [\"0.0.1\"]\ndefaultVersion: \"0.0.1\"\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(langDir, "draft.yaml"), []byte(draftYaml), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(langDir, "Dockerfile"), []byte("FROM golden\n"), 0644))
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "-m", "templates"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=draft", "-c", "user.email=draft@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(out))
+	}
+
+	// packs are installed in the home directory of the user
+	t.Setenv("HOME", t.TempDir())
+	tc := &templateCmd{}
+	assert.Nil(t, tc.add(repo))
+
+	var out bytes.Buffer
+	assert.Nil(t, tc.list(&out))
+	assert.Contains(t, out.String(), "golden")
+	assert.Contains(t, out.String(), "dockerfile-golden")
+	assert.NotContains(t, out.String(), "(modified)")
+
+	assert.Nil(t, loadTemplateDirs(true))
+	assert.True(t, handlers.IsValidTemplate("dockerfile-golden"))
+	languages, err := listSupportedLanguages()
+	assert.Nil(t, err)
+	assert.Contains(t, languages, "golden")
+
+	// a pack that no longer loads is skipped rather than failing every command
+	packDir := filepath.Join(tc.storeDir, "golden")
+	assert.Nil(t, os.WriteFile(filepath.Join(packDir, "dockerfiles", "golden", "draft.yaml"), []byte("templateName: [\n"), 0644))
+	checksum, err := dirhash.HashDir(packDir, "", dirhash.Hash1)
+	assert.Nil(t, err)
+	store, err := tc.store()
+	assert.Nil(t, err)
+	packs, err := store.List()
+	assert.Nil(t, err)
+	packs[0].Checksum, packs[0].Stamp = checksum, ""
+	index, err := yaml.Marshal(packs)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(tc.storeDir, "packs.yaml"), index, 0644))
+	assert.Len(t, installedTemplatePackDirs(), 1)
+	assert.Nil(t, loadTemplateDirs(true))
+	assert.False(t, handlers.IsValidTemplate("dockerfile-golden"))
+
+	assert.Nil(t, tc.remove("golden"))
+	assert.Empty(t, installedTemplatePackDirs())
+	assert.NotNil(t, tc.remove("golden"))
+}
+
+func TestIsTemplateCommand(t *testing.T) {
+	for args, expected := range map[string]bool{"template": true, "template remove": true, "template schema": true, "create": false, "validate": false} {
+		cmd, _, err := rootCmd.Find(strings.Fields(args))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, isTemplateCommand(cmd), args)
+	}
+}
+
+func TestTemplateSchemaCommand(t *testing.T) {
+	tc := &templateCmd{}
+	for name, template := range handlers.GetTemplates() {
+		var out bytes.Buffer
+		err := tc.schema(&out, name)
+		if template.Config.Abstract {
+			assert.ErrorContains(t, err, "abstract")
+			continue
+		}
+		assert.Nil(t, err, name)
+
+		var schema map[string]any
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &schema), name)
+		assert.Equal(t, "object", schema["type"], name)
+	}
+
+	var out bytes.Buffer
+	tc.version = "0.0.1"
+	assert.Nil(t, tc.schema(&out, "deployment-manifests"))
+	assert.Contains(t, out.String(), `"REPLICACOUNT": {`)
+	assert.Contains(t, out.String(), `"type": "integer"`)
+
+	tc.version = "9.9.9"
+	assert.NotNil(t, tc.schema(&out, "deployment-manifests"))
+	assert.ErrorContains(t, tc.schema(&out, "not-a-template"), "template not found")
+}
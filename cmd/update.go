@@ -22,6 +22,7 @@ type updateCmd struct {
 	provider                 string
 	addon                    string
 	flagVariables            []string
+	validateMode             string
 	templateWriter           templatewriter.TemplateWriter
 	templateVariableRecorder config.TemplateVariableRecorder
 }
@@ -49,6 +50,7 @@ func newUpdateCmd() *cobra.Command {
 	f.StringVarP(&uc.provider, "provider", "p", "azure", "cloud provider")
 	f.StringVarP(&uc.addon, "addon", "a", "", "addon name")
 	f.StringArrayVarP(&uc.flagVariables, "variable", "", []string{}, "pass template variables (e.g. --variable ingress-tls-cert-keyvault-uri=test.uri ingress-host=host)")
+	addValidateFlag(cmd, &uc.validateMode)
 
 	uc.templateWriter = &writers.LocalFSWriter{}
 
@@ -57,6 +59,9 @@ func newUpdateCmd() *cobra.Command {
 
 func (uc *updateCmd) run() error {
 	flagVariablesMap = flagVariablesToMap(uc.flagVariables)
+	if err := checkValidateMode(uc.validateMode); err != nil {
+		return err
+	}
 
 	if dryRun {
 		dryRunRecorder = dryrunpkg.NewDryRunRecorder()
@@ -79,7 +84,9 @@ func (uc *updateCmd) run() error {
 		return errors.New("DraftConfig is nil")
 	}
 
-	ingressTemplate.Config.VariableMapToDraftConfig(flagVariablesMap)
+	if err = ingressTemplate.Config.TypedVariableMapToDraftConfig(flagVariablesMap); err != nil {
+		return err
+	}
 
 	err = cmdhelpers.PromptAddonValues(uc.dest, ingressTemplate.Config)
 	if err != nil {
@@ -111,8 +118,9 @@ func (uc *updateCmd) run() error {
 				return err
 			}
 		}
+		return nil
 	}
-	return err
+	return validateGeneratedDeployment(uc.dest, "", uc.validateMode)
 }
 
 func init() {
@@ -3,20 +3,56 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
 
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/Azure/draft/pkg/merge"
 	"github.com/Azure/draft/pkg/safeguards"
+	"github.com/Azure/draft/pkg/safeguards/fix"
+	"github.com/Azure/draft/pkg/safeguards/preprocessing"
+	"github.com/Azure/draft/pkg/safeguards/report"
 	"github.com/Azure/draft/pkg/safeguards/types"
+	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"helm.sh/helm/v3/pkg/chartutil"
 )
 
+// stdinManifestPath is the manifest path that reads the manifests from stdin, e.g. `helm template . | draft validate -`
+const stdinManifestPath = "-"
+
 type validateCmd struct {
-	manifestPath     string
-	imagePullSecret  bool
-	releaseName      string
-	releaseNamespace string
+	manifestPath          string
+	stdin                 io.Reader
+	imagePullSecret       bool
+	releaseName           string
+	releaseNamespace      string
+	output                string
+	safeguardsConfig      string
+	exclude               []string
+	warn                  []string
+	parameters            []string
+	policyDirs            []string
+	valueFiles            []string
+	setValues             []string
+	chartCache            string
+	overlays              []string
+	relaxLoadRestrictions bool
+	fix                   bool
+	watch                 bool
+	schemaValidation      bool
+	kubernetesVersion     string
+	schemaLocations       []string
+	schemaCache           string
+	ignoreMissingSchemas  bool
+	targetK8sVersion      string
+	safeguardsVersion     string
 }
 
 func init() {
@@ -27,10 +63,18 @@ func newValidateCmd() *cobra.Command {
 	vc := &validateCmd{}
 
 	var cmd = &cobra.Command{
-		Use:   "validate",
+		Use:   "validate [path | -]",
 		Short: "Validates manifests against AKS best practices",
-		Long:  `This command validates manifests against several AKS best practices.`,
+		Long: `This command validates manifests against several AKS best practices.
+The manifests are read from stdin when the path is -, so the output of any renderer can be validated.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if vc.manifestPath != "" && vc.manifestPath != args[0] {
+					return fmt.Errorf("the manifest path can be given either as an argument or with --manifest, not both")
+				}
+				vc.manifestPath = args[0]
+			}
 			if err := vc.run(cmd); err != nil {
 				return err
 			}
@@ -40,72 +84,371 @@ func newValidateCmd() *cobra.Command {
 
 	f := cmd.Flags()
 
-	f.StringVarP(&vc.manifestPath, "manifest", "m", "", "'manifest' asks for the path to the manifest")
+	f.StringVarP(&vc.manifestPath, "manifest", "m", "", "'manifest' asks for the path to the manifest, or - to read the manifests from stdin")
 	f.BoolVarP(&vc.imagePullSecret, "imagePullSecret", "s", false, "'imagePullSecret' enables the Safeguard that checks for usage of an image pull secret within the manifest(s)")
 	f.StringVarP(&vc.releaseName, "releaseName", "n", "", "'releaseName' asks for a user-defined release name for the Helm package to use when rendering Helm projects in Draft")
 	f.StringVarP(&vc.releaseNamespace, "releaseNamespace", "e", "", "'releaseNamespace' asks for a user-defined release namespace for the Helm package to use when rendering Helm projects in Draft")
+	f.StringVarP(&vc.output, "output", "o", "", fmt.Sprintf("'output' prints the validation results in a machine-readable format instead of logging them (one of: %s)", strings.Join(report.Formats, ", ")))
+
+	f.StringVar(&vc.safeguardsVersion, "safeguards-version", "", "'safeguards-version' asks for the safeguards library version to validate against (e.g. v1.0.0), overriding the version pinned in the safeguards configuration, defaults to the latest version (see draft info)")
+	f.StringVar(&vc.safeguardsConfig, "safeguardsConfig", types.DefaultConfigPath, "'safeguardsConfig' asks for the path to a safeguards configuration file that excludes safeguards, overrides their parameters and sets their severity")
+	f.StringArrayVar(&vc.exclude, "exclude", []string{}, "'exclude' skips the named safeguard (e.g. --exclude pod-enforce-antiaffinity), can be repeated")
+	f.StringArrayVar(&vc.warn, "warn", []string{}, "'warn' reports violations of the named safeguard as warnings that do not fail validation, can be repeated")
+	f.StringArrayVar(&vc.parameters, "parameter", []string{}, "'parameter' overrides a safeguard parameter (e.g. --parameter container-allowed-images.imageRegex='^myacr.azurecr.io/.+$'), can be repeated")
+
+	f.StringArrayVar(&vc.policyDirs, "policy-dir", []string{}, "'policy-dir' adds the Gatekeeper constraint templates and constraints found in <dir>/<policy>/template.yaml and constraint.yaml to the safeguards, can be repeated")
+
+	f.StringArrayVarP(&vc.valueFiles, "values", "f", []string{}, "'values' asks for a values file applied on top of the chart's values.yaml when rendering Helm projects (e.g. -f charts/production.yaml), can be repeated")
+	f.StringArrayVar(&vc.setValues, "set", []string{}, "'set' overrides values when rendering Helm projects (e.g. --set replicas=2,image.tag=latest), can be repeated")
+
+	f.StringVar(&vc.chartCache, "chartCache", "", "'chartCache' asks for a local directory of chart archives, unpacked charts or a file-served index.yaml to resolve Helm dependencies that are not vendored in charts/")
+
+	f.StringArrayVar(&vc.overlays, "overlay", []string{}, "'overlay' asks for the name of an overlay under overlays/ to validate when given a kustomize root, all overlays are validated by default, can be repeated")
+	f.BoolVar(&vc.relaxLoadRestrictions, "relaxLoadRestrictions", false, "'relaxLoadRestrictions' allows kustomizations to load files outside of their own directory, like shared components")
+
+	f.BoolVar(&vc.schemaValidation, "schemaValidation", false, "'schemaValidation' validates the manifests against the Kubernetes schemas in strict mode, rejecting unknown fields, before running the safeguards")
+	f.StringVar(&vc.kubernetesVersion, "kubernetesVersion", "", "'kubernetesVersion' asks for the Kubernetes version whose schemas are used for schema validation (e.g. 1.30.0), defaults to --target-k8s-version, or to "+safeguards.EmbeddedSchemaVersion+" whose schemas are embedded")
+	f.StringArrayVar(&vc.schemaLocations, "schemaLocation", []string{}, "'schemaLocation' asks for a local kubernetes-json-schema directory or a schema URL template used for schema validation, can be repeated, defaults to the embedded schemas then the kubernetes-json-schema repository")
+	f.StringVar(&vc.schemaCache, "schemaCache", "", "'schemaCache' asks for the directory downloaded schemas are cached in so schema validation works offline afterwards, defaults to draft's user cache directory")
+	f.BoolVar(&vc.ignoreMissingSchemas, "ignoreMissingSchemas", false, "'ignoreMissingSchemas' skips objects without a schema, like custom resources, during schema validation")
+
+	f.StringVar(&vc.targetK8sVersion, "target-k8s-version", "", "'target-k8s-version' flags objects whose apiVersion is removed (as errors) or deprecated (as warnings) as of the given Kubernetes version (e.g. 1.29), with the apiVersion to use instead, and is the default --kubernetesVersion of schema validation")
+
+	f.BoolVar(&vc.watch, "watch", false, "'watch' keeps validating the manifests whenever a file under the manifest path changes and prints the violations that were introduced or resolved")
+	f.BoolVar(&vc.fix, "fix", false, "'fix' patches Deployments in place for missing resource limits, probes and pod anti-affinity and prints a diff of the changes, nothing is written with --dry-run")
 
 	return cmd
 }
 
+// getSafeguardsConfig loads the safeguards configuration file and layers the exclude, warn and parameter flags on top of it
+func (vc *validateCmd) getSafeguardsConfig(c *cobra.Command) (types.SafeguardsConfig, error) {
+	if c.Flags().Changed("safeguardsConfig") {
+		if _, err := os.Stat(vc.safeguardsConfig); err != nil {
+			return types.SafeguardsConfig{}, fmt.Errorf("reading safeguards config: %w", err)
+		}
+	}
+
+	config, err := safeguards.LoadConfig(vc.safeguardsConfig)
+	if err != nil {
+		return config, err
+	}
+	if config.Constraints == nil {
+		config.Constraints = make(map[string]types.ConstraintConfig)
+	}
+
+	config.Exclude = append(config.Exclude, vc.exclude...)
+
+	for _, name := range vc.warn {
+		cc := config.Constraints[name]
+		cc.Severity = types.SeverityWarning
+		config.Constraints[name] = cc
+	}
+
+	for _, parameter := range vc.parameters {
+		key, rawValue, ok := strings.Cut(parameter, "=")
+		name, param, hasParam := strings.Cut(key, ".")
+		if !ok || !hasParam || name == "" || param == "" {
+			return config, fmt.Errorf("invalid parameter %q, expected <safeguard>.<parameter>=<value>", parameter)
+		}
+
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(rawValue), &value); err != nil {
+			return config, fmt.Errorf("parsing value of parameter %s: %w", key, err)
+		}
+
+		cc := config.Constraints[name]
+		if cc.Parameters == nil {
+			cc.Parameters = make(map[string]interface{})
+		}
+		cc.Parameters[param] = value
+		config.Constraints[name] = cc
+	}
+
+	return config, nil
+}
+
 // run is our entry point to GetManifestResults
 func (vc *validateCmd) run(c *cobra.Command) error {
 	if vc.manifestPath == "" {
 		return fmt.Errorf("path to the manifests cannot be empty")
 	}
+	if vc.output != "" && !slices.Contains(report.Formats, vc.output) {
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", vc.output, strings.Join(report.Formats, ", "))
+	}
+	if vc.watch && (vc.output != "" || vc.fix) {
+		return fmt.Errorf("--watch cannot be combined with --output or --fix")
+	}
+	if vc.manifestPath == stdinManifestPath {
+		if vc.watch {
+			return fmt.Errorf("--watch cannot be used when reading manifests from stdin")
+		}
+		if len(vc.valueFiles) > 0 || len(vc.setValues) > 0 || len(vc.overlays) > 0 {
+			return fmt.Errorf("values files, --set overrides and overlays cannot be used when reading manifests from stdin, pass them to the renderer instead")
+		}
+	}
+	vc.stdin = c.InOrStdin()
 
-	// AddSafeguardCRIP just adds Container Restricted Image Pulls to the list of safeguards the client will review
-	// against the given manifest
-	if vc.imagePullSecret {
-		safeguards.AddSafeguardCRIP()
+	sgConfig, err := vc.getSafeguardsConfig(c)
+	if err != nil {
+		return err
 	}
 
-	var opt chartutil.ReleaseOptions
-	if vc.releaseName != "" {
-		opt.Name = vc.releaseName
+	opts := safeguards.ValidatorOptions{Version: vc.safeguardsVersion, Config: sgConfig}
+	// container-restricted-image-pulls is only evaluated when asked for with --imagePullSecret
+	if vc.imagePullSecret {
+		for _, sg := range types.Safeguards {
+			opts.Safeguards = append(opts.Safeguards, sg.Name)
+		}
+		opts.Safeguards = append(opts.Safeguards, types.Constraint_CRIP)
 	}
-	if vc.releaseNamespace != "" {
-		opt.Namespace = vc.releaseNamespace
+	for _, policyDir := range vc.policyDirs {
+		if isDir, err := safeguards.IsDirectory(policyDir); err != nil || !isDir {
+			return fmt.Errorf("adding policies: %s is not a directory", policyDir)
+		}
 	}
+	opts.PolicyDirs = vc.policyDirs
+
 	ctx := context.Background()
+	validator, err := safeguards.NewValidator(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("loading safeguards: %w", err)
+	}
+
+	if vc.watch {
+		watchCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return vc.runWatch(watchCtx, c, validator)
+	}
 
-	var manifestFiles []types.ManifestFile
-	manifestFiles, err := safeguards.GetManifestFiles(vc.manifestPath, opt)
+	manifestFiles, err := vc.getManifestFiles()
 	if err != nil {
-		return fmt.Errorf("error retrieving manifest files: %w", err)
+		return err
 	}
 
 	log.Debugf("validating manifests")
-	manifestViolations, err := safeguards.GetManifestResults(ctx, manifestFiles)
+	manifestViolations, err := vc.getResults(ctx, validator, manifestFiles)
 	if err != nil {
-		log.Errorf("validating safeguards: %s", err.Error())
 		return err
 	}
 
-	anyViolationsFound := false
-	for _, v := range manifestViolations {
-		log.Printf("Analyzing %s for violations", v.Name)
-		manifestHasViolations := false
-		// returning the full list of violations after each manifest is checked
-		for file, violations := range v.ObjectViolations {
-			log.Printf("  %s:", file)
-			for _, violation := range violations {
-				log.Printf("    ❌ %s", violation)
-				anyViolationsFound = true
-				manifestHasViolations = true
+	if vc.fix {
+		fixed, err := vc.fixManifests(c, manifestFiles, manifestViolations)
+		if err != nil {
+			return err
+		}
+		// validate again so only the violations that could not be fixed are reported
+		if fixed {
+			manifestViolations, err = vc.getResults(ctx, validator, manifestFiles)
+			if err != nil {
+				return err
 			}
 		}
-		if !manifestHasViolations {
-			log.Printf("    ✅ no violations found.")
+	}
+
+	if vc.output != "" {
+		if err := report.Write(c.OutOrStdout(), vc.output, manifestViolations); err != nil {
+			return err
+		}
+		if hasErrorViolations(manifestViolations) {
+			c.SilenceUsage = true
+			return fmt.Errorf("violations found")
 		}
+		return nil
 	}
 
+	anyViolationsFound, anyWarningsFound := logResults(manifestViolations)
+
 	if anyViolationsFound {
 		c.SilenceUsage = true // suppress default Cobra behaviour of printing usage on all errors
 		return fmt.Errorf("violations found")
+	} else if anyWarningsFound {
+		log.Printf("⚠️ Only warnings found in \"%s\".", vc.manifestPath)
 	} else {
 		log.Printf("✅ No violations found in \"%s\".", vc.manifestPath)
 	}
 
 	return nil
 }
+
+// getResults validates the manifest files against the Kubernetes schemas and the deprecated API versions, when enabled,
+// and the safeguards, merging the violations of every check per manifest in the order the checks ran
+func (vc *validateCmd) getResults(ctx context.Context, validator *safeguards.Validator, manifestFiles []types.ManifestFile) ([]types.ManifestResult, error) {
+	var checks [][]types.ManifestResult
+	if vc.schemaValidation {
+		kubernetesVersion, err := vc.schemaKubernetesVersion()
+		if err != nil {
+			return nil, err
+		}
+		schemaResults, err := safeguards.GetSchemaResults(manifestFiles, safeguards.SchemaOptions{
+			KubernetesVersion:    kubernetesVersion,
+			SchemaLocations:      vc.schemaLocations,
+			CacheDir:             vc.schemaCache,
+			IgnoreMissingSchemas: vc.ignoreMissingSchemas,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("validating schemas: %w", err)
+		}
+		checks = append(checks, schemaResults)
+	}
+	if vc.targetK8sVersion != "" {
+		deprecationResults, err := safeguards.GetDeprecationResults(manifestFiles, vc.targetK8sVersion)
+		if err != nil {
+			return nil, fmt.Errorf("checking api versions: %w", err)
+		}
+		checks = append(checks, deprecationResults)
+	}
+
+	results, err := validator.GetManifestResults(ctx, manifestFiles)
+	if err != nil {
+		log.Errorf("validating safeguards: %s", err.Error())
+		return nil, err
+	}
+	checks = append(checks, results)
+
+	merged := checks[0]
+	for _, checkResults := range checks[1:] {
+		for i := range merged {
+			merged[i].Merge(checkResults[i])
+		}
+	}
+	return merged, nil
+}
+
+// getManifestFiles reads or renders the manifests to validate
+func (vc *validateCmd) getManifestFiles() ([]types.ManifestFile, error) {
+	if vc.manifestPath == stdinManifestPath {
+		return safeguards.GetManifestFilesFromReader(vc.stdin, "stdin")
+	}
+
+	var opt chartutil.ReleaseOptions
+	if vc.releaseName != "" {
+		opt.Name = vc.releaseName
+	}
+	if vc.releaseNamespace != "" {
+		opt.Namespace = vc.releaseNamespace
+	}
+
+	helmOpts := &preprocessing.HelmOptions{
+		ValueFiles:    vc.valueFiles,
+		Values:        vc.setValues,
+		ChartCacheDir: vc.chartCache,
+	}
+	kustomizeOpts := &preprocessing.KustomizeOptions{
+		Overlays:              vc.overlays,
+		RelaxLoadRestrictions: vc.relaxLoadRestrictions,
+	}
+	manifestFiles, err := safeguards.GetManifestFilesWithOptions(vc.manifestPath, opt, helmOpts, kustomizeOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving manifest files: %w", err)
+	}
+	return manifestFiles, nil
+}
+
+// logResults logs the violations of every manifest grouped by object and reports whether errors or warnings were found
+func logResults(results []types.ManifestResult) (bool, bool) {
+	anyViolationsFound := false
+	anyWarningsFound := false
+	for _, v := range results {
+		log.Printf("Analyzing %s for violations", v.Name)
+		// returning the full list of violations after each manifest is checked, grouped by object
+		currentObject := ""
+		for _, violation := range v.Violations {
+			if violation.ObjectKey() != currentObject {
+				log.Printf("  %s %s:", violation.Kind, violation.Name)
+				currentObject = violation.ObjectKey()
+			}
+			if violation.Severity == types.SeverityWarning {
+				log.Printf("    ⚠️ %s", violation.Message)
+				anyWarningsFound = true
+			} else {
+				log.Printf("    ❌ %s", violation.Message)
+				anyViolationsFound = true
+			}
+		}
+		if len(v.Violations) == 0 {
+			log.Printf("    ✅ no violations found.")
+		}
+		for _, suppressed := range v.Suppressed {
+			log.Printf("    🔕 %s suppressed on %s: %s (reason: %s)", suppressed.Constraint, suppressed.Name, suppressed.Message, suppressed.Reason)
+		}
+	}
+
+	return anyViolationsFound, anyWarningsFound
+}
+
+// schemaKubernetesVersion returns the Kubernetes version of the schemas used for schema validation, which is the target
+// version of the api version checks unless --kubernetesVersion is given, so both checks validate against one version
+func (vc *validateCmd) schemaKubernetesVersion() (string, error) {
+	if vc.kubernetesVersion != "" || vc.targetK8sVersion == "" {
+		return vc.kubernetesVersion, nil
+	}
+	target, err := semver.NewVersion(vc.targetK8sVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid target Kubernetes version %s: %w", vc.targetK8sVersion, err)
+	}
+	// schemas are published per patch version, e.g. 1.29.0
+	return target.String(), nil
+}
+
+// fixManifests patches the manifest files for their fixable violations and prints a diff of every change. Files are
+// rewritten unless running with --dry-run, and the manifests are updated in place for validating them again.
+func (vc *validateCmd) fixManifests(c *cobra.Command, manifestFiles []types.ManifestFile, results []types.ManifestResult) (bool, error) {
+	// keep machine-readable output parseable by printing diffs alongside the logs
+	diffWriter := c.OutOrStdout()
+	if vc.output != "" {
+		diffWriter = c.ErrOrStderr()
+	}
+
+	anyFixed := false
+	for i, result := range results {
+		if !slices.ContainsFunc(result.Violations, func(v types.Violation) bool { return fix.Fixable(v.Constraint) }) {
+			continue
+		}
+
+		m := &manifestFiles[i]
+		if m.Rendered || m.Source == "" {
+			log.Warnf("cannot fix %s: it was not read from a manifest file, fix its sources instead", m.Name)
+			continue
+		}
+
+		content, changes, err := fix.Manifest(m.ManifestContent, result.Violations)
+		if err != nil {
+			return anyFixed, fmt.Errorf("fixing %s: %w", m.Source, err)
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		for _, change := range changes {
+			log.Printf("🔧 %s: %s", m.Name, change)
+		}
+		if _, err = diffWriter.Write(merge.Diff(m.Source, m.ManifestContent, content)); err != nil {
+			return anyFixed, fmt.Errorf("printing diff: %w", err)
+		}
+
+		if !dryRun {
+			if err = os.WriteFile(m.Source, content, 0644); err != nil {
+				return anyFixed, fmt.Errorf("writing fixed manifest: %w", err)
+			}
+		}
+		m.ManifestContent = content
+		anyFixed = true
+	}
+
+	if anyFixed && dryRun {
+		log.Printf("dry run: no fixes were written to disk")
+	}
+	return anyFixed, nil
+}
+
+// hasErrorViolations reports whether any manifest has a violation that should fail validation
+func hasErrorViolations(results []types.ManifestResult) bool {
+	for _, r := range results {
+		for _, v := range r.Violations {
+			if v.Severity != types.SeverityWarning {
+				return true
+			}
+		}
+	}
+	return false
+}
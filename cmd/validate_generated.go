@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Azure/draft/pkg/filematches"
+	"github.com/Azure/draft/pkg/safeguards"
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// Modes of the --validate flag of the commands that write deployment files
+const (
+	validateModeError = "error"
+	validateModeWarn  = "warn"
+)
+
+// addValidateFlag adds the opt-in --validate flag, which fails on safeguards violations when given without a value
+func addValidateFlag(cmd *cobra.Command, mode *string) {
+	f := cmd.Flags()
+	f.StringVar(mode, "validate", emptyDefaultFlagValue, fmt.Sprintf("validate the written deployment files against the AKS Deployment Safeguards, failing on violations or only logging them (%s, %s)", validateModeError, validateModeWarn))
+	f.Lookup("validate").NoOptDefVal = validateModeError
+}
+
+// checkValidateMode rejects an unknown --validate mode before any file is written
+func checkValidateMode(mode string) error {
+	switch mode {
+	case emptyDefaultFlagValue, validateModeError, validateModeWarn:
+		return nil
+	default:
+		return fmt.Errorf("invalid validate mode %q, must be one of: %s, %s", mode, validateModeError, validateModeWarn)
+	}
+}
+
+// validateGeneratedDeployment renders the deployment files of the given type written to dest and validates them against
+// the safeguards, honoring the safeguards configuration of the project. Violations fail with an error in error mode and
+// are only logged in warn mode. The deployment type is detected from dest when empty.
+func validateGeneratedDeployment(dest, deployType, mode string) error {
+	if mode == emptyDefaultFlagValue {
+		return nil
+	}
+	if dryRun {
+		log.Info("skipping safeguards validation, no files were written with --dry-run")
+		return nil
+	}
+
+	if deployType == "" {
+		var err error
+		if deployType, err = filematches.FindDraftDeploymentFiles(dest); err != nil {
+			return fmt.Errorf("finding deployment files to validate: %w", err)
+		}
+	}
+
+	manifestPath := dest
+	switch deployType {
+	case "helm":
+		manifestPath = filepath.Join(dest, "charts")
+	case "kustomize":
+		// dest is the kustomize root holding base and overlays
+	case "manifests":
+		manifestPath = filepath.Join(dest, "manifests")
+	default:
+		return fmt.Errorf("cannot validate unknown deployment type %q", deployType)
+	}
+
+	sgConfig, err := safeguards.LoadConfig(filepath.Join(dest, types.DefaultConfigPath))
+	if err != nil {
+		return err
+	}
+	validator, err := safeguards.NewValidator(context.Background(), safeguards.ValidatorOptions{Config: sgConfig})
+	if err != nil {
+		return fmt.Errorf("loading safeguards: %w", err)
+	}
+
+	log.Infof("--> Validating %s against the AKS Deployment Safeguards...", manifestPath)
+	manifestFiles, err := safeguards.GetManifestFiles(manifestPath, chartutil.ReleaseOptions{})
+	if err != nil {
+		return fmt.Errorf("rendering deployment files: %w", err)
+	}
+	results, err := validator.GetManifestResults(context.Background(), manifestFiles)
+	if err != nil {
+		return fmt.Errorf("validating deployment files: %w", err)
+	}
+
+	anyViolationsFound, anyWarningsFound := logResults(results)
+	switch {
+	case anyViolationsFound && mode == validateModeError:
+		return fmt.Errorf("the deployment files in %s violate the AKS Deployment Safeguards, fix them or run `draft validate --fix` before committing", manifestPath)
+	case anyViolationsFound || anyWarningsFound:
+		log.Warnf("⚠️ The deployment files in %s would be flagged by the AKS Deployment Safeguards.", manifestPath)
+	default:
+		log.Infof("✅ No violations found in %s.", manifestPath)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+// generateTestDeployment writes a deployment of the given type to a temporary project directory
+func generateTestDeployment(t *testing.T, deployType string) string {
+	dest := t.TempDir()
+	flagVariablesMap = map[string]string{"PORT": "8080", "APPNAME": "testapp", "SERVICEPORT": "8080", "NAMESPACE": "testnamespace", "IMAGENAME": "testimage", "IMAGETAG": "latest", "ENVVARS": `{"LOG_LEVEL":"info"}`}
+	cc := createCmd{
+		dest:           dest,
+		createConfig:   &CreateConfig{DeployType: deployType},
+		templateWriter: &writers.LocalFSWriter{},
+	}
+	assert.Nil(t, cc.generateDeployment())
+	return dest
+}
+
+func TestValidateGeneratedDeployment(t *testing.T) {
+	for _, deployType := range []string{"helm", "kustomize", "manifests"} {
+		dest := generateTestDeployment(t, deployType)
+		assert.Nil(t, validateGeneratedDeployment(dest, deployType, validateModeError), deployType)
+		// the deployment type is detected when not given, like after draft update
+		assert.Nil(t, validateGeneratedDeployment(dest, "", validateModeError), deployType)
+	}
+}
+
+func TestValidateGeneratedDeploymentViolations(t *testing.T) {
+	dest := generateTestDeployment(t, "manifests")
+	unsafe, err := os.ReadFile("../pkg/safeguards/tests/fix/fixable-manifest.yaml")
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(filepath.Join(dest, "manifests", "unsafe.yaml"), unsafe, 0644))
+
+	assert.NotNil(t, validateGeneratedDeployment(dest, "manifests", validateModeError))
+	assert.Nil(t, validateGeneratedDeployment(dest, "manifests", validateModeWarn))
+	// validation is opt-in
+	assert.Nil(t, validateGeneratedDeployment(dest, "manifests", emptyDefaultFlagValue))
+}
+
+func TestValidateGeneratedDeploymentDryRun(t *testing.T) {
+	dryRun = true
+	defer func() { dryRun = false }()
+
+	// nothing is written with --dry-run, so there is nothing to validate
+	assert.Nil(t, validateGeneratedDeployment(t.TempDir(), "manifests", validateModeError))
+}
+
+func TestCheckValidateMode(t *testing.T) {
+	assert.Nil(t, checkValidateMode(emptyDefaultFlagValue))
+	assert.Nil(t, checkValidateMode(validateModeError))
+	assert.Nil(t, checkValidateMode(validateModeWarn))
+	assert.NotNil(t, checkValidateMode("strict"))
+}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/draft/pkg/safeguards"
@@ -107,4 +111,229 @@ func TestRunValidate_Kustomize(t *testing.T) {
 	assert.Nil(t, err)
 	numViolations = countTestViolations(v)
 	assert.Equal(t, numViolations, 1)
+
+	// the violating object violates two safeguards, each counted by the results
+	violationsCount := 0
+	for _, r := range v {
+		violationsCount += r.ViolationsCount
+	}
+	assert.Equal(t, 2, violationsCount)
+}
+
+// TestRunValidate_Output tests that `draft validate --output` writes machine-readable results
+func TestRunValidate_Output(t *testing.T) {
+	cmd := newValidateCmd()
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--manifest", manifestPathFileError, "--output", "json"})
+	assert.NotNil(t, cmd.Execute())
+
+	var results []types.ManifestResult
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &results))
+	assert.NotEmpty(t, results)
+	assert.NotEmpty(t, results[0].Violations)
+	for _, v := range results[0].Violations {
+		assert.NotEmpty(t, v.Constraint)
+		assert.NotEmpty(t, v.Kind)
+		assert.Equal(t, manifestPathFileError, v.File)
+		assert.Greater(t, v.Line, 0)
+	}
+
+	cmd = newValidateCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"--manifest", manifestPathFileError, "--output", "yaml"})
+	assert.NotNil(t, cmd.Execute())
+}
+
+// TestValidateSafeguardsConfig tests that the exclude, warn and parameter flags are layered on top of the config file
+func TestValidateSafeguardsConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "safeguards.yaml")
+	err := os.WriteFile(configPath, []byte("exclude: [restricted-taints]\n"), 0644)
+	assert.Nil(t, err)
+
+	vc := &validateCmd{
+		safeguardsConfig: configPath,
+		exclude:          []string{types.Constraint_PEA},
+		warn:             []string{types.Constraint_CRL},
+		parameters: []string{
+			"container-allowed-images.imageRegex=^myacr.azurecr.io/.+$",
+			"restricted-taints.reservedTaints=[a, b]",
+		},
+	}
+	cmd := newValidateCmd()
+	assert.Nil(t, cmd.Flags().Set("safeguardsConfig", configPath))
+
+	config, err := vc.getSafeguardsConfig(cmd)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{types.Constraint_RT, types.Constraint_PEA}, config.Exclude)
+	assert.Equal(t, types.SeverityWarning, config.Constraints[types.Constraint_CRL].Severity)
+	assert.Equal(t, "^myacr.azurecr.io/.+$", config.Constraints[types.Constraint_CAI].Parameters["imageRegex"])
+	assert.Equal(t, []interface{}{"a", "b"}, config.Constraints[types.Constraint_RT].Parameters["reservedTaints"])
+
+	vc.parameters = []string{"imageRegex=foo"}
+	_, err = vc.getSafeguardsConfig(cmd)
+	assert.NotNil(t, err)
+
+	vc.parameters = nil
+	vc.safeguardsConfig = filepath.Join(t.TempDir(), "missing.yaml")
+	_, err = vc.getSafeguardsConfig(cmd)
+	assert.NotNil(t, err)
+}
+
+// TestRunValidate_Fix tests that --fix patches the fixable violations of a manifest and leaves it untouched on a dry run
+func TestRunValidate_Fix(t *testing.T) {
+	original, err := os.ReadFile("../pkg/safeguards/tests/fix/fixable-manifest.yaml")
+	assert.Nil(t, err)
+	manifestPath := filepath.Join(t.TempDir(), "fixable-manifest.yaml")
+	assert.Nil(t, os.WriteFile(manifestPath, original, 0644))
+
+	dryRun = true
+	cmd := newValidateCmd()
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--manifest", manifestPath, "--fix"})
+	err = cmd.Execute()
+	dryRun = false
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "+        livenessProbe:")
+	content, err := os.ReadFile(manifestPath)
+	assert.Nil(t, err)
+	assert.Equal(t, original, content)
+
+	cmd = newValidateCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"--manifest", manifestPath, "--fix"})
+	assert.Nil(t, cmd.Execute())
+	content, err = os.ReadFile(manifestPath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "image: nginx:latest # pinned by the release pipeline")
+	assert.Contains(t, string(content), "preferredDuringSchedulingIgnoredDuringExecution:")
+
+	// the fixed manifest passes without --fix
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPath})
+	assert.Nil(t, cmd.Execute())
+}
+
+// TestRunValidate_Stdin tests that manifests piped to `draft validate -` are validated
+func TestRunValidate_Stdin(t *testing.T) {
+	for _, tc := range []struct {
+		manifest string
+		args     []string
+		wantErr  bool
+	}{
+		{manifest: manifestPathFileSuccess, args: []string{"-"}},
+		{manifest: manifestPathFileError, args: []string{"--manifest", "-"}, wantErr: true},
+	} {
+		content, err := os.ReadFile(tc.manifest)
+		assert.Nil(t, err)
+
+		cmd := newValidateCmd()
+		cmd.SetIn(bytes.NewReader(content))
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetArgs(tc.args)
+		err = cmd.Execute()
+		if tc.wantErr {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+	}
+
+	cmd := newValidateCmd()
+	cmd.SetIn(bytes.NewReader([]byte{}))
+	cmd.SetArgs([]string{"-", "--set", "replicas=2"})
+	assert.ErrorContains(t, cmd.Execute(), "stdin")
+
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{manifestPathFileSuccess, "--manifest", manifestPathFileError})
+	assert.NotNil(t, cmd.Execute())
+}
+
+// TestRunValidate_SchemaValidation tests that schema errors are reported alongside the safeguard violations
+func TestRunValidate_SchemaValidation(t *testing.T) {
+	schemaArgs := []string{"--schemaValidation", "--kubernetesVersion", "1.30.0", "--schemaLocation", "../pkg/safeguards/tests/schemas", "--schemaCache", t.TempDir()}
+
+	cmd := newValidateCmd()
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	cmd.SetArgs(append([]string{"--manifest", "../pkg/safeguards/tests/schema/invalid-manifest.yaml", "--output", "json"}, schemaArgs...))
+	assert.NotNil(t, cmd.Execute())
+
+	var results []types.ManifestResult
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &results))
+	assert.Len(t, results, 1)
+	var schemaViolations, safeguardViolations int
+	for _, v := range results[0].Violations {
+		if v.Constraint == types.Constraint_Schema {
+			schemaViolations++
+		} else {
+			safeguardViolations++
+		}
+	}
+	assert.Equal(t, 2, schemaViolations)
+	assert.Greater(t, safeguardViolations, 0)
+	assert.Equal(t, types.Constraint_Schema, results[0].Violations[0].Constraint)
+
+	cmd = newValidateCmd()
+	cmd.SetArgs(append([]string{"--manifest", manifestPathFileSuccess}, schemaArgs...))
+	assert.Nil(t, cmd.Execute())
+
+	// the schemas default to the target version of the api version checks
+	cmd = newValidateCmd()
+	out = new(bytes.Buffer)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--manifest", "../pkg/safeguards/tests/schema/invalid-manifest.yaml", "--output", "json", "--schemaValidation", "--target-k8s-version", "1.30", "--schemaLocation", "../pkg/safeguards/tests/schemas", "--schemaCache", t.TempDir()})
+	assert.NotNil(t, cmd.Execute())
+	results = nil
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &results))
+	assert.Equal(t, "/spec: additional properties 'replica' not allowed", results[0].Violations[0].Message)
+}
+
+// TestRunValidate_TargetK8sVersion tests that removed and deprecated api versions are reported for the target version
+func TestRunValidate_TargetK8sVersion(t *testing.T) {
+	cmd := newValidateCmd()
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--manifest", "../pkg/safeguards/tests/deprecated-apis/deprecated-manifest.yaml", "--target-k8s-version", "1.29", "--output", "json"})
+	assert.NotNil(t, cmd.Execute())
+
+	var results []types.ManifestResult
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &results))
+	var deprecated []string
+	for _, v := range results[0].Violations {
+		if v.Constraint == types.Constraint_DeprecatedAPI {
+			deprecated = append(deprecated, v.Kind)
+		}
+	}
+	assert.Equal(t, []string{"Ingress", "CronJob", "FlowSchema"}, deprecated)
+
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--target-k8s-version", "1.29"})
+	assert.Nil(t, cmd.Execute())
+
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--target-k8s-version", "latest"})
+	assert.NotNil(t, cmd.Execute())
+}
+
+func TestRunValidate_SafeguardsVersion(t *testing.T) {
+	cmd := newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--safeguards-version", "v1.0.0"})
+	assert.Nil(t, cmd.Execute())
+
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--safeguards-version", "v9.0.0"})
+	assert.ErrorContains(t, cmd.Execute(), "unsupported safeguards version")
+
+	// a version pinned in the safeguards configuration is used unless overridden
+	configPath := filepath.Join(t.TempDir(), "safeguards.yaml")
+	assert.Nil(t, os.WriteFile(configPath, []byte("version: v9.0.0\n"), 0644))
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--safeguardsConfig", configPath})
+	assert.ErrorContains(t, cmd.Execute(), "unsupported safeguards version")
+
+	cmd = newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileSuccess, "--safeguardsConfig", configPath, "--safeguards-version", "v1.0.0"})
+	assert.Nil(t, cmd.Execute())
 }
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/draft/pkg/safeguards"
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// watchDebounce is how long a burst of file events, like an editor saving several files, may take before validating
+const watchDebounce = 300 * time.Millisecond
+
+// runWatch validates the manifests once and again after every change under the watched paths until ctx is done, printing
+// the violations introduced or resolved by each change. The validator keeps the safeguards loaded between validations.
+func (vc *validateCmd) runWatch(ctx context.Context, c *cobra.Command, validator *safeguards.Validator) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, root := range vc.watchRoots() {
+		if err = addWatchDirs(watcher, root); err != nil {
+			return err
+		}
+	}
+
+	previous, err := vc.validateWith(ctx, validator)
+	if err != nil {
+		return err
+	}
+	logResults(previous)
+
+	out := c.OutOrStdout()
+	fmt.Fprintf(out, "👀 watching %s for changes, press Ctrl+C to stop\n", vc.manifestPath)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// new directories, like a freshly added overlay, are watched too
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err = addWatchDirs(watcher, event.Name); err != nil {
+						log.Warnf("watching %s: %s", event.Name, err.Error())
+					}
+				}
+			}
+			debounce = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warnf("watching manifests: %s", err.Error())
+		case <-debounce:
+			debounce = nil
+			results, err := vc.validateWith(ctx, validator)
+			if err != nil {
+				// charts are often broken halfway through an edit, keep watching for the next change
+				log.Errorf("validating manifests: %s", err.Error())
+				continue
+			}
+			added, resolved := diffViolations(previous, results)
+			printViolationChanges(out, added, resolved, results)
+			previous = results
+		}
+	}
+}
+
+// validateWith renders the manifests again and validates them with the already loaded safeguards
+func (vc *validateCmd) validateWith(ctx context.Context, validator *safeguards.Validator) ([]types.ManifestResult, error) {
+	manifestFiles, err := vc.getManifestFiles()
+	if err != nil {
+		return nil, err
+	}
+	return vc.getResults(ctx, validator, manifestFiles)
+}
+
+// watchRoots returns the paths whose changes affect the rendered manifests: the manifest path, or the directory of a
+// manifest file, and the directories of the Helm values files
+func (vc *validateCmd) watchRoots() []string {
+	roots := []string{vc.manifestPath}
+	if isDir, err := safeguards.IsDirectory(vc.manifestPath); err == nil && !isDir {
+		roots[0] = filepath.Dir(vc.manifestPath)
+	}
+	for _, valueFile := range vc.valueFiles {
+		if dir := filepath.Dir(valueFile); !slices.Contains(roots, dir) {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// addWatchDirs watches root and every directory below it, skipping hidden directories like .git
+func addWatchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walking %s: %w", p, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err = watcher.Add(p); err != nil {
+			return fmt.Errorf("watching %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// violationKey identifies a violation across validations, ignoring its line since edits above an object move it
+func violationKey(v types.Violation) string {
+	return strings.Join([]string{v.File, v.ObjectKey(), v.Constraint, v.Message}, "\x00")
+}
+
+// diffViolations returns the violations only found in the current results and the ones only found in the previous results
+func diffViolations(previous, current []types.ManifestResult) ([]types.Violation, []types.Violation) {
+	collect := func(results []types.ManifestResult) ([]types.Violation, map[string]bool) {
+		var violations []types.Violation
+		keys := make(map[string]bool)
+		for _, r := range results {
+			for _, v := range r.Violations {
+				violations = append(violations, v)
+				keys[violationKey(v)] = true
+			}
+		}
+		return violations, keys
+	}
+	previousViolations, previousKeys := collect(previous)
+	currentViolations, currentKeys := collect(current)
+
+	var added, resolved []types.Violation
+	for _, v := range currentViolations {
+		if !previousKeys[violationKey(v)] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range previousViolations {
+		if !currentKeys[violationKey(v)] {
+			resolved = append(resolved, v)
+		}
+	}
+	return added, resolved
+}
+
+// printViolationChanges prints the violations a change introduced or resolved along with how many remain
+func printViolationChanges(out io.Writer, added, resolved []types.Violation, results []types.ManifestResult) {
+	remaining := 0
+	for _, r := range results {
+		remaining += len(r.Violations)
+	}
+
+	fmt.Fprintf(out, "🔄 %s: validated %d manifests\n", time.Now().Format(time.TimeOnly), len(results))
+	for _, v := range added {
+		icon := "❌"
+		if v.Severity == types.SeverityWarning {
+			icon = "⚠️"
+		}
+		fmt.Fprintf(out, "  %s new in %s: %s %s: %s\n", icon, v.File, v.Kind, v.Name, v.Message)
+	}
+	for _, v := range resolved {
+		fmt.Fprintf(out, "  ✅ resolved in %s: %s %s: %s\n", v.File, v.Kind, v.Name, v.Message)
+	}
+	if len(added) == 0 && len(resolved) == 0 {
+		fmt.Fprintf(out, "  no change in violations\n")
+	}
+	fmt.Fprintf(out, "  %d violations remaining\n", remaining)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/safeguards"
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while the watch loop writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestRunValidate_Watch tests that fixing a manifest while watching reports its violations as resolved
+func TestRunValidate_Watch(t *testing.T) {
+	errorManifest, err := os.ReadFile(manifestPathFileError)
+	assert.Nil(t, err)
+	successManifest, err := os.ReadFile(manifestPathFileSuccess)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.yaml")
+	assert.Nil(t, os.WriteFile(manifestPath, errorManifest, 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	validator, err := safeguards.NewValidator(ctx, safeguards.ValidatorOptions{})
+	assert.Nil(t, err)
+
+	out := &syncBuffer{}
+	cmd := newValidateCmd()
+	cmd.SetOut(out)
+	vc := &validateCmd{manifestPath: dir}
+
+	done := make(chan error)
+	go func() { done <- vc.runWatch(ctx, cmd, validator) }()
+
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "watching") }, 10*time.Second, 50*time.Millisecond)
+	assert.Nil(t, os.WriteFile(manifestPath, successManifest, 0644))
+	assert.Eventually(t, func() bool { return strings.Contains(out.String(), "0 violations remaining") }, 10*time.Second, 50*time.Millisecond)
+	assert.Contains(t, out.String(), "✅ resolved in "+manifestPath)
+
+	cancel()
+	assert.Nil(t, <-done)
+}
+
+func TestRunValidate_WatchFlags(t *testing.T) {
+	cmd := newValidateCmd()
+	cmd.SetArgs([]string{"--manifest", manifestPathFileError, "--watch", "--output", "json"})
+	assert.ErrorContains(t, cmd.Execute(), "--watch cannot be combined")
+}
+
+func TestDiffViolations(t *testing.T) {
+	probes := types.Violation{Constraint: types.Constraint_CEP, Kind: "Deployment", Name: "web", Message: "missing probes", File: "deployment.yaml", Line: 1}
+	limits := types.Violation{Constraint: types.Constraint_CRL, Kind: "Deployment", Name: "web", Message: "missing limits", File: "deployment.yaml", Line: 1}
+	moved := probes
+	moved.Line = 5
+
+	previous := []types.ManifestResult{{Name: "deployment.yaml", Violations: []types.Violation{probes, limits}}}
+	current := []types.ManifestResult{{Name: "deployment.yaml", Violations: []types.Violation{moved}}}
+
+	added, resolved := diffViolations(previous, current)
+	assert.Empty(t, added)
+	assert.Equal(t, []types.Violation{limits}, resolved)
+
+	added, resolved = diffViolations(current, previous)
+	assert.Equal(t, []types.Violation{limits}, added)
+	assert.Empty(t, resolved)
+}
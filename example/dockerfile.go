@@ -45,7 +45,9 @@ func WriteDockerfileExample() error {
 
 	// Set the variable values within the template
 	for k, v := range templateVars {
-		d.Config.SetVariable(k, v)
+		if err := d.Config.SetTypedVariable(k, v); err != nil {
+			return fmt.Errorf("failed to set variable %s: %w", k, err)
+		}
 	}
 
 	// Generate the dockerfile files
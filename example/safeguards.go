@@ -0,0 +1,60 @@
+package example
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/draft/pkg/safeguards"
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// ValidateManifestExample shows how to set up a reusable Validator and validate a manifest against the safeguards
+func ValidateManifestExample() error {
+	ctx := context.Background()
+
+	// Create a validator for the safeguards to enable (must correspond to safeguards of the selected version), it can be
+	// shared by every goroutine of a service
+	v, err := safeguards.NewValidator(ctx, safeguards.ValidatorOptions{
+		Version:    "v1.0.0",
+		Safeguards: []string{types.Constraint_CEP, types.Constraint_CRL},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create validator: %w", err)
+	}
+
+	// Read the manifests to validate
+	manifest := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example-app
+spec:
+  selector:
+    matchLabels:
+      app: example-app
+  template:
+    metadata:
+      labels:
+        app: example-app
+    spec:
+      containers:
+        - name: example-app
+          image: example-image:latest
+`)
+	manifestFiles := []types.ManifestFile{{Name: "deployment.yaml", ManifestContent: manifest}}
+
+	// Validate the manifests
+	results, err := v.GetManifestResults(ctx, manifestFiles)
+	if err != nil {
+		return fmt.Errorf("failed to validate manifest: %w", err)
+	}
+
+	// Read the violations of every manifest
+	fmt.Printf("Violations found in ValidateManifestExample:\n")
+	for _, result := range results {
+		for _, violation := range result.Violations {
+			fmt.Printf("  %s %s: %s\n", violation.Kind, violation.Name, violation.Message)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package example
+
+import (
+	"testing"
+)
+
+func TestValidateManifestExample(t *testing.T) {
+	err := ValidateManifestExample()
+	if err != nil {
+		t.Errorf("ValidateManifestExample failed: %e", err)
+		t.Fail()
+	}
+}
@@ -52,7 +52,9 @@ func PromptAddonValues(dest string, addonConfig *config.DraftConfig) error {
 		if strings.Contains(strings.ToLower(refName), "namespace") && refVal == "" {
 			refVal = "default" //hack here to have explicit namespacing, probably a better way to do this
 		}
-		addonConfig.SetVariable(refName, refVal)
+		if err = addonConfig.SetTypedVariable(refName, refVal); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -23,12 +23,12 @@ func TestPromptAddonValues(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, ingressTemplate)
 
-	ingressTemplate.Config.SetVariable("ingress-tls-cert-keyvault-uri", "test.keyvault.uri")
-	ingressTemplate.Config.SetVariable("ingress-use-osm-mtls", "false")
-	ingressTemplate.Config.SetVariable("ingress-host", "test.host")
-	ingressTemplate.Config.SetVariable("service-name", "test")
-	ingressTemplate.Config.SetVariable("service-namespace", "test")
-	ingressTemplate.Config.SetVariable("service-port", "80")
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("ingress-tls-cert-keyvault-uri", "test.keyvault.uri"))
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("ingress-use-osm-mtls", "false"))
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("ingress-host", "test.host"))
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("service-name", "test"))
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("service-namespace", "test"))
+	assert.Nil(t, ingressTemplate.Config.SetTypedVariable("service-port", "80"))
 
 	err = PromptAddonValues(dir, ingressTemplate.Config)
 	assert.Nil(t, err)
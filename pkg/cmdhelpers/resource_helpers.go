@@ -0,0 +1,155 @@
+package cmdhelpers
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Azure/draft/pkg/templatewriter"
+)
+
+const (
+	appNameLabel = "app.kubernetes.io/name"
+	partOfLabel  = "app.kubernetes.io/part-of"
+)
+
+// deploymentFilePaths maps each deployment type to the file holding its Deployment object
+var deploymentFilePaths = map[string]string{
+	"kustomize": "base/deployment.yaml",
+	"manifests": "manifests/deployment.yaml",
+}
+
+// GetDeploymentReferenceValues reads the existing draft deployment in dest and returns the
+// APPNAME, PARTOF and NAMESPACE values used to prefill standalone manifest templates. PARTOF is the
+// part-of label of the Deployment, rendered with the chart's default values for helm, and defaults to APPNAME.
+func GetDeploymentReferenceValues(deployType, dest string) (map[string]string, error) {
+	var appName, partOf, namespace string
+
+	switch deployType {
+	case "helm":
+		helmChart, err := loader.Load(filepath.Join(dest, "charts"))
+		if err != nil {
+			return nil, fmt.Errorf("loading helm chart: %w", err)
+		}
+		appName = helmChart.Name()
+		if ns, ok := helmChart.Values["namespace"].(string); ok {
+			namespace = ns
+		}
+		deployment, err := renderHelmDeployment(helmChart, chartutil.ReleaseOptions{Name: appName, Namespace: namespace})
+		if err != nil {
+			return nil, err
+		}
+		if deployment != nil {
+			partOf = deployment.GetLabels()[partOfLabel]
+		}
+	case "kustomize", "manifests":
+		deployment, err := yaml.ReadFile(filepath.Join(dest, deploymentFilePaths[deployType]))
+		if err != nil {
+			return nil, fmt.Errorf("reading deployment: %w", err)
+		}
+		appName = deployment.GetName()
+		namespace = deployment.GetNamespace()
+		partOf = deployment.GetLabels()[partOfLabel]
+		if appName == "" {
+			appName = deployment.GetLabels()[appNameLabel]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported deployment type: %s", deployType)
+	}
+
+	if appName == "" {
+		return nil, fmt.Errorf("could not determine app name from %s deployment in %s", deployType, dest)
+	}
+	if partOf == "" {
+		partOf = appName
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	log.Debugf("found deployment reference values: app=%s partOf=%s namespace=%s", appName, partOf, namespace)
+	return map[string]string{
+		"APPNAME":   appName,
+		"PARTOF":    partOf,
+		"NAMESPACE": namespace,
+	}, nil
+}
+
+// renderHelmDeployment renders a chart with its default values and returns its Deployment, or nil if it has none
+func renderHelmDeployment(helmChart *chart.Chart, opt chartutil.ReleaseOptions) (*yaml.RNode, error) {
+	values, err := chartutil.ToRenderValues(helmChart, nil, opt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building render values: %w", err)
+	}
+	renderedFiles, err := engine.Render(helmChart, values)
+	if err != nil {
+		return nil, fmt.Errorf("rendering helm chart: %w", err)
+	}
+
+	// sort the rendered files so the Deployment found is the same between runs
+	fileNames := make([]string, 0, len(renderedFiles))
+	for fileName := range renderedFiles {
+		fileNames = append(fileNames, fileName)
+	}
+	slices.Sort(fileNames)
+
+	for _, fileName := range fileNames {
+		if !strings.HasSuffix(fileName, ".yaml") && !strings.HasSuffix(fileName, ".yml") {
+			continue
+		}
+		nodes, err := kio.FromBytes([]byte(renderedFiles[fileName]))
+		if err != nil {
+			return nil, fmt.Errorf("parsing rendered %s: %w", fileName, err)
+		}
+		for _, node := range nodes {
+			if node.GetKind() == "Deployment" {
+				return node, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// AddKustomizeResources appends the given resource paths to the resources list of a kustomization file,
+// skipping any resources that are already listed
+func AddKustomizeResources(kustomizationPath string, resources []string, templateWriter templatewriter.TemplateWriter) error {
+	kustomization, err := yaml.ReadFile(kustomizationPath)
+	if err != nil {
+		return fmt.Errorf("reading kustomization: %w", err)
+	}
+
+	resourceList, err := kustomization.Pipe(yaml.LookupCreate(yaml.SequenceNode, "resources"))
+	if err != nil {
+		return fmt.Errorf("looking up kustomization resources: %w", err)
+	}
+
+	var existing []string
+	for _, node := range resourceList.Content() {
+		existing = append(existing, node.Value)
+	}
+
+	for _, resource := range resources {
+		if slices.Contains(existing, resource) {
+			log.Debugf("resource %s already listed in %s", resource, kustomizationPath)
+			continue
+		}
+		resourceList.YNode().Content = append(resourceList.YNode().Content, yaml.NewStringRNode(resource).YNode())
+		existing = append(existing, resource)
+	}
+
+	out, err := kustomization.String()
+	if err != nil {
+		return fmt.Errorf("serializing kustomization: %w", err)
+	}
+
+	return templateWriter.WriteFile(kustomizationPath, []byte(out))
+}
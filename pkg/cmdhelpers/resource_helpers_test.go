@@ -0,0 +1,64 @@
+package cmdhelpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+func TestGetDeploymentReferenceValues(t *testing.T) {
+	for _, deployType := range []string{"helm", "kustomize", "manifests"} {
+		refValues, err := GetDeploymentReferenceValues(deployType, filepath.Join(templatePath, deployType))
+		assert.Nil(t, err)
+		assert.Equal(t, "test", refValues["APPNAME"])
+		assert.Equal(t, "test", refValues["PARTOF"])
+		assert.NotEmpty(t, refValues["NAMESPACE"])
+	}
+
+	_, err := GetDeploymentReferenceValues("unsupported", templatePath)
+	assert.NotNil(t, err)
+}
+
+func TestGetDeploymentReferenceValuesPartOf(t *testing.T) {
+	for _, deployType := range []string{"helm", "kustomize", "manifests"} {
+		dest := t.TempDir()
+		assert.Nil(t, os.CopyFS(dest, os.DirFS(filepath.Join(templatePath, deployType))))
+
+		deploymentPath := filepath.Join(dest, "charts/templates/deployment.yaml")
+		if deployType != "helm" {
+			deploymentPath = filepath.Join(dest, deploymentFilePaths[deployType])
+		}
+		deployment, err := os.ReadFile(deploymentPath)
+		assert.Nil(t, err)
+		// every deployment type labels its Deployment metadata under "labels:"
+		labeled := strings.Replace(string(deployment), "labels:", "labels:\n    app.kubernetes.io/part-of: shop", 1)
+		assert.Nil(t, os.WriteFile(deploymentPath, []byte(labeled), 0644))
+
+		refValues, err := GetDeploymentReferenceValues(deployType, dest)
+		assert.Nil(t, err, deployType)
+		assert.Equal(t, "test", refValues["APPNAME"], deployType)
+		assert.Equal(t, "shop", refValues["PARTOF"], deployType)
+	}
+}
+
+func TestAddKustomizeResources(t *testing.T) {
+	kustomizationPath := filepath.Join(templatePath, "kustomize/overlays/production/kustomization.yaml")
+	templateWriter := &writers.FileMapWriter{}
+
+	err := AddKustomizeResources(kustomizationPath, []string{"hpa.yaml", "../../base"}, templateWriter)
+	assert.Nil(t, err)
+
+	out := string(templateWriter.FileMap[kustomizationPath])
+	assert.Contains(t, out, "- hpa.yaml")
+	assert.Equal(t, 1, strings.Count(out, "../../base"))
+	assert.Contains(t, out, "patchesStrategicMerge")
+
+	original, err := os.ReadFile(kustomizationPath)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(original), "hpa.yaml")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"slices"
+	"strings"
 
 	"github.com/Azure/draft/pkg/config/transformers"
 	"github.com/Azure/draft/pkg/config/validators"
@@ -34,6 +35,8 @@ type DraftConfig struct {
 	TemplateName        string                         `yaml:"templateName"`
 	DisplayName         string                         `yaml:"displayName"`
 	Description         string                         `yaml:"description"`
+	Extends             string                         `yaml:"extends"`  // the templateName of the parent template
+	Abstract            bool                           `yaml:"abstract"` // abstract templates can only be extended, not generated
 	Type                string                         `yaml:"type"`
 	Versions            []string                       `yaml:"versions"`
 	DefaultVersion      string                         `yaml:"defaultVersion"`
@@ -54,6 +57,8 @@ type BuilderVar struct {
 	Kind                  string                 `yaml:"kind"`
 	Value                 string                 `yaml:"value"`
 	Versions              string                 `yaml:"versions"`
+
+	typed *typedValue
 }
 
 // BuilderVarDefault holds info on the default value of a variable
@@ -61,6 +66,23 @@ type BuilderVarDefault struct {
 	IsPromptDisabled bool   `yaml:"disablePrompt"`
 	ReferenceVar     string `yaml:"referenceVar"`
 	Value            string `yaml:"value"`
+
+	// promptDisabledSet records whether the config sets disablePrompt, so a child template can re-enable the prompt
+	promptDisabledSet bool
+}
+
+// UnmarshalYAML records whether disablePrompt is set alongside the default itself
+func (d *BuilderVarDefault) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BuilderVarDefault
+	if err := unmarshal((*plain)(d)); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	_, d.promptDisabledSet = fields["disablePrompt"]
+	return nil
 }
 
 // ActiveWhenConstraints holds information on when a variable is actively used by a template based off other variable values
@@ -125,38 +147,73 @@ func (d *DraftConfig) GetVariableValue(name string) (any, error) {
 				return "", fmt.Errorf("failed variable validation: %w", err)
 			}
 
-			response, err := d.GetVariableTransformer(variable.Kind)(variable.Value)
-			if err != nil {
-				return "", fmt.Errorf("failed variable transformation: %w", err)
+			// kinds with a transformer are rendered as transformed, the others as the declared type
+			if transformer, ok := d.lookupVariableTransformer(variable.Kind); ok {
+				response, err := transformer(variable.Value)
+				if err != nil {
+					return "", fmt.Errorf("failed variable transformation: %w", err)
+				}
+				return response, nil
 			}
 
-			return response, nil
+			return variable.TypedValue()
 		}
 	}
 
 	return "", fmt.Errorf("variable %s not found", name)
 }
 
+// SetVariable sets the value of a variable like SetTypedVariable, logging a value that does not parse as the declared
+// type of the variable and leaving the variable unchanged.
+//
+// Deprecated: use SetTypedVariable, which returns the error.
 func (d *DraftConfig) SetVariable(name, value string) {
-	if variable, err := d.GetVariable(name); err != nil {
+	if err := d.SetTypedVariable(name, value); err != nil {
+		log.Errorf("not setting variable %s: %s", name, err)
+	}
+}
+
+// SetTypedVariable sets the value of a variable, adding an untyped variable if the config has none with the name.
+// The value must parse as the declared type of the variable.
+func (d *DraftConfig) SetTypedVariable(name, value string) error {
+	variable, err := d.GetVariable(name)
+	if err != nil {
 		d.Variables = append(d.Variables, &BuilderVar{
 			Name:  name,
 			Value: value,
 		})
-	} else {
-		variable.Value = value
+		return nil
 	}
+
+	previous := variable.Value
+	variable.Value = value
+	if _, err = variable.TypedValue(); err != nil {
+		variable.Value = previous
+		return err
+	}
+	return nil
 }
 
 // GetVariableTransformer returns the transformer for a specific variable kind
 func (d *DraftConfig) GetVariableTransformer(kind string) VariableTransformer {
+	if transformer, ok := d.lookupVariableTransformer(kind); ok {
+		return transformer
+	}
+	return transformers.DefaultTransformer
+}
+
+// lookupVariableTransformer returns the transformer for a specific variable kind, reporting whether the kind has one
+func (d *DraftConfig) lookupVariableTransformer(kind string) (VariableTransformer, bool) {
 	// user overrides
 	if transformer, ok := d.Transformers[kind]; ok {
-		return transformer
+		return transformer, true
 	}
 
 	// internally defined transformers
-	return transformers.GetTransformer(kind)
+	if transformer, ok := transformers.LookupTransformer(kind); ok {
+		return transformer, true
+	}
+	return nil, false
 }
 
 // GetVariableValidator returns the validator for a specific variable kind
@@ -223,6 +280,10 @@ func (d *DraftConfig) ApplyDefaultVariables() error {
 		} else {
 			log.Infof("Variable %s already set to value %s", variable.Name, variable.Value)
 		}
+
+		if _, err := variable.TypedValue(); err != nil {
+			return fmt.Errorf("apply default variables: %w", err)
+		}
 	}
 
 	return nil
@@ -283,6 +344,12 @@ func (d *DraftConfig) ApplyDefaultVariablesForVersion(version string) error {
 				}
 			}
 		}
+
+		if variable.Value != "" {
+			if _, err := variable.TypedValue(); err != nil {
+				return fmt.Errorf("apply default variables: %w", err)
+			}
+		}
 	}
 
 	return nil
@@ -316,11 +383,12 @@ func (d *DraftConfig) CheckActiveWhenConstraint(variable *BuilderVar) (bool, err
 				}
 			}
 
+			checkValue = comparableValue(refVar.Type, checkValue)
 			switch activeWhen.Condition {
 			case EqualTo:
-				isVarActive = checkValue == activeWhen.Value
+				isVarActive = checkValue == comparableValue(refVar.Type, activeWhen.Value)
 			case NotEqualTo:
-				isVarActive = checkValue != activeWhen.Value
+				isVarActive = checkValue != comparableValue(refVar.Type, activeWhen.Value)
 			default:
 				return false, fmt.Errorf("invalid activeWhen condition: %s", activeWhen.Condition)
 			}
@@ -352,7 +420,10 @@ func (d *DraftConfig) recurseReferenceVars(referenceVar *BuilderVar, variableChe
 	return referenceVar.Default.Value, nil
 }
 
-// handles flags that are meant to represent template variables
+// VariableMapToDraftConfig handles flags that are meant to represent template variables like
+// TypedVariableMapToDraftConfig, logging the values that do not parse as the declared type of their variable.
+//
+// Deprecated: use TypedVariableMapToDraftConfig, which returns the error.
 func (d *DraftConfig) VariableMapToDraftConfig(flagVariablesMap map[string]string) {
 	for flagName, flagValue := range flagVariablesMap {
 		log.Debugf("flag variable %s=%s", flagName, flagValue)
@@ -360,6 +431,18 @@ func (d *DraftConfig) VariableMapToDraftConfig(flagVariablesMap map[string]strin
 	}
 }
 
+// TypedVariableMapToDraftConfig handles flags that are meant to represent template variables, returning an error if a
+// value does not parse as the declared type of its variable
+func (d *DraftConfig) TypedVariableMapToDraftConfig(flagVariablesMap map[string]string) error {
+	for flagName, flagValue := range flagVariablesMap {
+		log.Debugf("flag variable %s=%s", flagName, flagValue)
+		if err := d.SetTypedVariable(flagName, flagValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetFileNameOverride sets the filename override for a specific file
 func (d *DraftConfig) SetFileNameOverride(input, override string) {
 	if d.FileNameOverrideMap == nil {
@@ -373,6 +456,8 @@ func (d *DraftConfig) DeepCopy() *DraftConfig {
 		TemplateName:        d.TemplateName,
 		DisplayName:         d.DisplayName,
 		Description:         d.Description,
+		Extends:             d.Extends,
+		Abstract:            d.Abstract,
 		Type:                d.Type,
 		Versions:            make([]string, len(d.Versions)),
 		DefaultVersion:      d.DefaultVersion,
@@ -395,6 +480,130 @@ func (d *DraftConfig) DeepCopy() *DraftConfig {
 	return newConfig
 }
 
+// ResolveExtends merges every config with the config it extends, after the parent is merged with its own parent. Configs
+// are keyed by their lower case template name.
+func ResolveExtends(configs map[string]*DraftConfig) error {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	resolved := make(map[string]bool)
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		d := configs[name]
+		if resolved[name] || d.Extends == "" {
+			return nil
+		}
+		chain = append(chain, d.TemplateName)
+		parentName := strings.ToLower(d.Extends)
+		parent, ok := configs[parentName]
+		if !ok {
+			return fmt.Errorf("template %s extends unknown template %s", d.TemplateName, d.Extends)
+		}
+		if slices.ContainsFunc(chain, func(n string) bool { return strings.EqualFold(n, parentName) }) {
+			return fmt.Errorf("template inheritance cycle: %s -> %s", strings.Join(chain, " -> "), d.Extends)
+		}
+		if err := resolve(parentName, chain); err != nil {
+			return err
+		}
+
+		d.Inherit(parent)
+		resolved[name] = true
+		return nil
+	}
+
+	for _, name := range names {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inherit merges the type, versions, variables, filename overrides, validators and transformers of a parent config into
+// the config. Values of the config win: a variable declared by both is merged field by field, the fields the config sets
+// overriding the ones of the parent. Inherited variables come first, in the order of the parent.
+func (d *DraftConfig) Inherit(parent *DraftConfig) {
+	if d.Type == "" {
+		d.Type = parent.Type
+	}
+	if d.Description == "" {
+		d.Description = parent.Description
+	}
+	if len(d.Versions) == 0 {
+		d.Versions = slices.Clone(parent.Versions)
+	}
+	if d.DefaultVersion == "" {
+		d.DefaultVersion = parent.DefaultVersion
+	}
+
+	variables := make([]*BuilderVar, 0, len(parent.Variables)+len(d.Variables))
+	for _, variable := range parent.Variables {
+		variables = append(variables, variable.DeepCopy())
+	}
+	for _, variable := range d.Variables {
+		i := slices.IndexFunc(variables, func(v *BuilderVar) bool { return v.Name == variable.Name })
+		if i < 0 {
+			variables = append(variables, variable)
+			continue
+		}
+		variable.inherit(variables[i])
+		variables[i] = variable
+	}
+	d.Variables = variables
+
+	for k, v := range parent.FileNameOverrideMap {
+		if _, ok := d.FileNameOverrideMap[k]; !ok {
+			d.SetFileNameOverride(k, v)
+		}
+	}
+	for kind, validator := range parent.Validators {
+		if _, ok := d.Validators[kind]; !ok {
+			d.SetVariableValidator(kind, validator)
+		}
+	}
+	for kind, transformer := range parent.Transformers {
+		if _, ok := d.Transformers[kind]; !ok {
+			d.SetVariableTransformer(kind, transformer)
+		}
+	}
+}
+
+// inherit fills the fields the variable leaves empty from the same variable of a parent template. The default value or
+// reference is inherited only when the variable sets neither, and disablePrompt only when the variable does not set it.
+func (bv *BuilderVar) inherit(parent *BuilderVar) {
+	inheritString := func(field *string, parentValue string) {
+		if *field == "" {
+			*field = parentValue
+		}
+	}
+	inheritString(&bv.Description, parent.Description)
+	inheritString(&bv.Type, parent.Type)
+	inheritString(&bv.Kind, parent.Kind)
+	inheritString(&bv.Value, parent.Value)
+	inheritString(&bv.Versions, parent.Versions)
+	if bv.Default.Value == "" && bv.Default.ReferenceVar == "" {
+		bv.Default.Value = parent.Default.Value
+		bv.Default.ReferenceVar = parent.Default.ReferenceVar
+	}
+	if !bv.Default.promptDisabledSet {
+		bv.Default.IsPromptDisabled = parent.Default.IsPromptDisabled
+		bv.Default.promptDisabledSet = parent.Default.promptDisabledSet
+	}
+
+	if bv.ActiveWhenConstraints == nil {
+		bv.ActiveWhenConstraints = slices.Clone(parent.ActiveWhenConstraints)
+	}
+	if bv.ExampleValues == nil {
+		bv.ExampleValues = slices.Clone(parent.ExampleValues)
+	}
+	if bv.AllowedValues == nil {
+		bv.AllowedValues = slices.Clone(parent.AllowedValues)
+	}
+}
+
 func (bv *BuilderVar) DeepCopy() *BuilderVar {
 	newVar := &BuilderVar{
 		Name:                  bv.Name,
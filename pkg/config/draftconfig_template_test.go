@@ -29,6 +29,8 @@ var validVariableTypes = map[string]bool{
 	"bool":   true,
 	"int":    true,
 	"float":  true,
+	"list":   true,
+	"map":    true,
 	"object": true,
 }
 var validVariableKinds = map[string]bool{
@@ -76,9 +78,11 @@ This test will validate all the templates in the templates directory have:
 1. a unique template name
 2. a valid template type
 3. a non-empty variable name
-4. a valid variable type
+4. a valid variable type, and a default value of that type
 5. a valid variable kind
 
+Templates are validated merged with the templates they extend.
+
 Append this for more validation
 */
 func TestTempalteValidation(t *testing.T) {
@@ -87,6 +91,10 @@ func TestTempalteValidation(t *testing.T) {
 
 func loadTemplatesWithValidation() error {
 	regexp := regexp.MustCompile(alphaNumUnderscoreHyphen)
+	resolvedTemplates, err := loadResolvedTemplates()
+	if err != nil {
+		return err
+	}
 	return fs.WalkDir(template.Templates, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -100,11 +108,7 @@ func loadTemplatesWithValidation() error {
 			return nil
 		}
 
-		currTemplate, err := NewConfigFromFS(template.Templates, path)
-		if err != nil {
-			return err
-		}
-
+		currTemplate := resolvedTemplates[path]
 		if currTemplate == nil {
 			return fmt.Errorf("template %s is nil", path)
 		}
@@ -143,6 +147,12 @@ func loadTemplatesWithValidation() error {
 				return fmt.Errorf("template %s has an invalid variable(%s) type: %s", path, variable.Name, variable.Type)
 			}
 
+			if variable.Default.Value != "" {
+				if _, err := ParseVariableValue(variable.Type, variable.Default.Value); err != nil {
+					return fmt.Errorf("template %s has a variable %s with an invalid default value: %w", path, variable.Name, err)
+				}
+			}
+
 			if _, ok := validVariableKinds[variable.Kind]; !ok {
 				return fmt.Errorf("template %s has an invalid variable kind: %s", path, variable.Kind)
 			}
@@ -208,6 +218,28 @@ func loadTemplatesWithValidation() error {
 	})
 }
 
+// loadResolvedTemplates loads the templates by their path, merged with the templates they extend like draft loads them
+func loadResolvedTemplates() (map[string]*DraftConfig, error) {
+	byPath := map[string]*DraftConfig{}
+	byName := map[string]*DraftConfig{}
+	err := fs.WalkDir(template.Templates, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(d.Name(), draftConfigFile) {
+			return err
+		}
+		currTemplate, err := NewConfigFromFS(template.Templates, path)
+		if err != nil {
+			return err
+		}
+		byPath[path] = currTemplate
+		byName[strings.ToLower(currTemplate.TemplateName)] = currTemplate
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return byPath, ResolveExtends(byName)
+}
+
 func isCyclicalDefaultVariableReference(initialVar, currRefVar *BuilderVar, allVariables map[string]*BuilderVar, visited map[string]bool) bool {
 	if initialVar.Name == currRefVar.Name {
 		return true
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"reflect"
+	"slices"
 	"testing"
+
+	"gopkg.in/yaml.v2"
 )
 
 func TestApplyDefaultVariables(t *testing.T) {
@@ -177,6 +182,22 @@ func TestApplyDefaultVariables(t *testing.T) {
 			want:       map[string]string{},
 			wantErrMsg: "apply default variables: cyclical reference detected",
 		},
+		{
+			testName: "defaultNotOfDeclaredType",
+			draftConfig: DraftConfig{
+				Variables: []*BuilderVar{
+					{
+						Name: "var1",
+						Type: "int",
+						Default: BuilderVarDefault{
+							Value: "two",
+						},
+					},
+				},
+			},
+			want:       map[string]string{},
+			wantErrMsg: `apply default variables: invalid value for int variable var1: "two" is not an int`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
@@ -365,3 +386,170 @@ func TestApplyDefaultVariablesForVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestSetTypedVariable(t *testing.T) {
+	draftConfig := DraftConfig{
+		Variables: []*BuilderVar{
+			{Name: "REPLICACOUNT", Type: "int", Value: "1"},
+			{Name: "ENABLED", Type: "bool"},
+		},
+	}
+
+	if err := draftConfig.SetTypedVariable("REPLICACOUNT", "two"); err == nil {
+		t.Error("expected an error setting an int variable to two")
+	}
+	if err := draftConfig.SetTypedVariable("ENABLED", "yes"); err == nil {
+		t.Error("expected an error setting a bool variable to yes")
+	}
+	if variable, _ := draftConfig.GetVariable("REPLICACOUNT"); variable.Value != "1" {
+		t.Errorf("got: %s, want: 1, an invalid value must not be set", variable.Value)
+	}
+
+	if err := draftConfig.SetTypedVariable("REPLICACOUNT", "3"); err != nil {
+		t.Error(err)
+	}
+	// variables the config does not declare are untyped
+	if err := draftConfig.SetTypedVariable("UNDECLARED", "two"); err != nil {
+		t.Error(err)
+	}
+	if err := draftConfig.TypedVariableMapToDraftConfig(map[string]string{"ENABLED": "maybe"}); err == nil {
+		t.Error("expected an error mapping an invalid bool flag")
+	}
+
+	// the deprecated SetVariable checks the type too
+	draftConfig.SetVariable("REPLICACOUNT", "four")
+	if variable, _ := draftConfig.GetVariable("REPLICACOUNT"); variable.Value != "3" {
+		t.Errorf("got: %s, want: 3, an invalid value must not be set", variable.Value)
+	}
+}
+
+func TestGetVariableValueTyped(t *testing.T) {
+	draftConfig := DraftConfig{
+		Variables: []*BuilderVar{
+			{Name: "REPLICACOUNT", Type: "int", Value: "3"},
+			{Name: "ENABLED", Type: "bool", Value: "false"},
+			{Name: "VERSION", Value: "6.0"},
+			{Name: "SDKVERSION", Type: "float", Value: "5.0"},
+			{Name: "ENVVARS", Type: "object", Kind: "envVarMap", Value: `{"key":"value"}`},
+			{Name: "HOSTS", Type: "list", Value: `["a","b"]`},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want any
+	}{
+		{"REPLICACOUNT", 3},
+		{"ENABLED", false},
+		{"VERSION", "6.0"},
+		// floats keep their literal text
+		{"SDKVERSION", json.Number("5.0")},
+		// the envVarMap transformer has the final say over the type
+		{"ENVVARS", map[string]string{"key": "value"}},
+		{"HOSTS", []any{"a", "b"}},
+	}
+	for _, tt := range tests {
+		got, err := draftConfig.GetVariableValue(tt.name)
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got: %#v, want: %#v, for variable: %s", got, tt.want, tt.name)
+		}
+	}
+
+	// values set around SetVariable are still checked when rendered
+	draftConfig.Variables[0].Value = "two"
+	if _, err := draftConfig.GetVariableValue("REPLICACOUNT"); err == nil {
+		t.Error("expected an error getting an int variable set to two")
+	}
+}
+
+func TestInherit(t *testing.T) {
+	parent := &DraftConfig{
+		Type:           "dockerfile",
+		Versions:       []string{"0.0.1"},
+		DefaultVersion: "0.0.1",
+		Variables: []*BuilderVar{
+			{Name: "PORT", Type: "int", Kind: "port", Versions: ">=0.0.1", Default: BuilderVarDefault{Value: "80"}},
+			{Name: "VERSION", Type: "string", Kind: "containerImageVersion", Versions: ">=0.0.1", ExampleValues: []string{"1"}},
+			{Name: "DOCKERFILENAME", Type: "string", Kind: "dockerFileName", Versions: ">=0.0.1", Default: BuilderVarDefault{Value: "Dockerfile", IsPromptDisabled: true}},
+		},
+		FileNameOverrideMap: map[string]string{"Dockerfile": "Dockerfile.parent", "README.md": "README.parent.md"},
+	}
+	child := &DraftConfig{
+		TemplateName: "child",
+		Variables: []*BuilderVar{
+			{Name: "ENTRYPOINT", Type: "string", Kind: "filePath", Versions: ">=0.0.1"},
+			{Name: "VERSION", Type: "float", Default: BuilderVarDefault{Value: "2.0"}},
+			{Name: "PORT", Default: BuilderVarDefault{ReferenceVar: "ENTRYPOINT"}},
+		},
+		FileNameOverrideMap: map[string]string{"Dockerfile": "Dockerfile.child"},
+	}
+	child.Inherit(parent)
+
+	if child.Type != "dockerfile" || child.DefaultVersion != "0.0.1" || len(child.Versions) != 1 {
+		t.Errorf("template fields not inherited: %+v", child)
+	}
+
+	var names []string
+	for _, v := range child.Variables {
+		names = append(names, v.Name)
+	}
+	if want := []string{"PORT", "VERSION", "DOCKERFILENAME", "ENTRYPOINT"}; !slices.Equal(names, want) {
+		t.Errorf("got variables %v, want %v", names, want)
+	}
+
+	version, _ := child.GetVariable("VERSION")
+	if version.Type != "float" || version.Kind != "containerImageVersion" || version.Default.Value != "2.0" || !slices.Equal(version.ExampleValues, []string{"1"}) {
+		t.Errorf("VERSION not merged: %+v", version)
+	}
+	// a default reference of the child replaces the default value of the parent
+	port, _ := child.GetVariable("PORT")
+	if port.Default.Value != "" || port.Default.ReferenceVar != "ENTRYPOINT" || port.Kind != "port" {
+		t.Errorf("PORT not merged: %+v", port)
+	}
+	if dockerfileName, _ := child.GetVariable("DOCKERFILENAME"); !dockerfileName.Default.IsPromptDisabled {
+		t.Errorf("DOCKERFILENAME prompt enabled")
+	}
+
+	if child.FileNameOverrideMap["Dockerfile"] != "Dockerfile.child" || child.FileNameOverrideMap["README.md"] != "README.parent.md" {
+		t.Errorf("got filename overrides %v", child.FileNameOverrideMap)
+	}
+
+	// the parent is left untouched
+	if parentVersion, _ := parent.GetVariable("VERSION"); parentVersion.Type != "string" || len(parent.Variables) != 3 {
+		t.Errorf("parent modified: %+v", parent)
+	}
+}
+
+func TestInheritDisablePrompt(t *testing.T) {
+	parent := &DraftConfig{
+		Variables: []*BuilderVar{
+			{Name: "PORT", Type: "int", Default: BuilderVarDefault{Value: "80", IsPromptDisabled: true}},
+			{Name: "VERSION", Type: "string", Default: BuilderVarDefault{Value: "1", IsPromptDisabled: true}},
+		},
+	}
+	var child DraftConfig
+	childYaml := `
+variables:
+  - name: PORT
+    default:
+      disablePrompt: false
+  - name: VERSION
+    default:
+      value: "2"
+`
+	if err := yaml.Unmarshal([]byte(childYaml), &child); err != nil {
+		t.Fatal(err)
+	}
+	child.Inherit(parent)
+
+	// the child's explicit setting wins over the parent's
+	if port, _ := child.GetVariable("PORT"); port.Default.IsPromptDisabled {
+		t.Errorf("PORT prompt disabled")
+	}
+	if version, _ := child.GetVariable("VERSION"); !version.Default.IsPromptDisabled {
+		t.Errorf("VERSION prompt enabled")
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/blang/semver/v4"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the schemas generated from draft configs
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema describing the variables of a draft config. Draft specific annotations are
+// prefixed with x-draft-.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Examples             []any                  `json:"examples,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	If                   *JSONSchema            `json:"if,omitempty"`
+	Then                 *JSONSchema            `json:"then,omitempty"`
+	Else                 *JSONSchema            `json:"else,omitempty"`
+	Not                  *JSONSchema            `json:"not,omitempty"`
+
+	Kind             string `json:"x-draft-kind,omitempty"`
+	Versions         string `json:"x-draft-versions,omitempty"`
+	DefaultReference string `json:"x-draft-default-reference,omitempty"`
+	PromptDisabled   bool   `json:"x-draft-prompt-disabled,omitempty"`
+}
+
+// jsonSchemaTypes maps variable types to JSON Schema types, object variables accepting any JSON value
+var jsonSchemaTypes = map[string]string{
+	"":                 "string",
+	VariableTypeString: "string",
+	VariableTypeInt:    "integer",
+	VariableTypeFloat:  "number",
+	VariableTypeBool:   "boolean",
+	VariableTypeList:   "array",
+	VariableTypeMap:    "object",
+	VariableTypeObject: "",
+}
+
+// JSONSchema generates a JSON Schema for the variables of the config, describing an object with a property for each
+// variable holding a value of its declared type. Variables without a default are required, and variables with
+// activeWhen constraints are only required, and only accepted, when their constraints hold. An empty version describes
+// the variables of every version, otherwise only the variables of the version are described.
+func (d *DraftConfig) JSONSchema(version string) (*JSONSchema, error) {
+	var v semver.Version
+	if version != "" {
+		var err error
+		if v, err = semver.Parse(version); err != nil {
+			return nil, fmt.Errorf("invalid version: %w", err)
+		}
+		if !slices.Contains(d.Versions, version) {
+			return nil, fmt.Errorf("requested version outside of valid versions: %s", version)
+		}
+	}
+
+	title := d.DisplayName
+	if title == "" {
+		title = d.TemplateName
+	}
+	additionalProperties := false
+	schema := &JSONSchema{
+		Schema:               JSONSchemaDialect,
+		Title:                title,
+		Description:          d.Description,
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: &additionalProperties,
+	}
+
+	variables := make(map[string]*BuilderVar)
+	for _, variable := range d.Variables {
+		if version != "" && variable.Versions != "" {
+			expectedRange, err := semver.ParseRange(variable.Versions)
+			if err != nil {
+				return nil, fmt.Errorf("invalid versions of variable %s: %w", variable.Name, err)
+			}
+			if !expectedRange(v) {
+				continue
+			}
+		}
+		variables[variable.Name] = variable
+
+		property, err := variable.jsonSchema()
+		if err != nil {
+			return nil, err
+		}
+		schema.Properties[variable.Name] = property
+	}
+
+	for _, variable := range d.Variables {
+		if _, ok := variables[variable.Name]; !ok {
+			continue
+		}
+		hasDefault := variable.Default.Value != "" || variable.Default.ReferenceVar != ""
+		if len(variable.ActiveWhenConstraints) == 0 {
+			if !hasDefault {
+				schema.Required = append(schema.Required, variable.Name)
+			}
+			continue
+		}
+
+		condition, err := activeWhenJSONSchema(variable, variables)
+		if err != nil {
+			return nil, err
+		}
+		conditional := &JSONSchema{
+			If:   condition,
+			Else: &JSONSchema{Not: &JSONSchema{Required: []string{variable.Name}}},
+		}
+		if !hasDefault {
+			conditional.Then = &JSONSchema{Required: []string{variable.Name}}
+		}
+		schema.AllOf = append(schema.AllOf, conditional)
+	}
+
+	return schema, nil
+}
+
+// jsonSchema returns the schema of the values of a variable
+func (bv *BuilderVar) jsonSchema() (*JSONSchema, error) {
+	schemaType, ok := jsonSchemaTypes[bv.Type]
+	if !ok {
+		return nil, fmt.Errorf("variable %s has unknown type %s", bv.Name, bv.Type)
+	}
+	schema := &JSONSchema{
+		Description:      bv.Description,
+		Type:             schemaType,
+		Kind:             bv.Kind,
+		Versions:         bv.Versions,
+		DefaultReference: bv.Default.ReferenceVar,
+		PromptDisabled:   bv.Default.IsPromptDisabled,
+	}
+
+	var err error
+	if bv.Default.Value != "" {
+		if schema.Default, err = bv.parseValue(bv.Default.Value, "default"); err != nil {
+			return nil, err
+		}
+	}
+	for _, allowed := range bv.AllowedValues {
+		value, err := bv.parseValue(allowed, "allowed")
+		if err != nil {
+			return nil, err
+		}
+		schema.Enum = append(schema.Enum, value)
+	}
+	for _, example := range bv.ExampleValues {
+		value, err := bv.parseValue(example, "example")
+		if err != nil {
+			return nil, err
+		}
+		schema.Examples = append(schema.Examples, value)
+	}
+	return schema, nil
+}
+
+// parseValue parses a value declared by the variable as its type
+func (bv *BuilderVar) parseValue(value, valueName string) (any, error) {
+	parsed, err := ParseVariableValue(bv.Type, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value for %s variable %s: %w", valueName, bv.Type, bv.Name, err)
+	}
+	return parsed, nil
+}
+
+// activeWhenJSONSchema returns the condition under which a variable is active. A referenced variable left out of the
+// input holds its default value, so it is only required when its default does not satisfy the constraint.
+func activeWhenJSONSchema(variable *BuilderVar, variables map[string]*BuilderVar) (*JSONSchema, error) {
+	condition := &JSONSchema{Properties: make(map[string]*JSONSchema)}
+	for _, activeWhen := range variable.ActiveWhenConstraints {
+		refVar, ok := variables[activeWhen.VariableName]
+		if !ok {
+			return nil, fmt.Errorf("variable %s is active when unknown variable %s", variable.Name, activeWhen.VariableName)
+		}
+		value, err := refVar.parseValue(activeWhen.Value, "activeWhen")
+		if err != nil {
+			return nil, err
+		}
+
+		var satisfiedByDefault bool
+		switch activeWhen.Condition {
+		case EqualTo:
+			condition.Properties[refVar.Name] = &JSONSchema{Const: value}
+			satisfiedByDefault = comparableValue(refVar.Type, refVar.Default.Value) == comparableValue(refVar.Type, activeWhen.Value)
+		case NotEqualTo:
+			condition.Properties[refVar.Name] = &JSONSchema{Not: &JSONSchema{Const: value}}
+			satisfiedByDefault = refVar.Default.Value != "" && comparableValue(refVar.Type, refVar.Default.Value) != comparableValue(refVar.Type, activeWhen.Value)
+		default:
+			return nil, fmt.Errorf("invalid activeWhen condition: %s", activeWhen.Condition)
+		}
+		if !satisfiedByDefault && !slices.Contains(condition.Required, refVar.Name) {
+			condition.Required = append(condition.Required, refVar.Name)
+		}
+	}
+	return condition, nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	draftConfig := DraftConfig{
+		TemplateName: "deployment-test",
+		Description:  "a test deployment",
+		Versions:     []string{"0.0.1", "0.0.2"},
+		Variables: []*BuilderVar{
+			{Name: "APPNAME", Type: "string", Kind: "kubernetesResourceName", Versions: ">=0.0.1"},
+			{Name: "REPLICACOUNT", Type: "int", Default: BuilderVarDefault{Value: "1"}, ExampleValues: []string{"1", "3"}, Versions: ">=0.0.1"},
+			{Name: "PROBETYPE", Type: "string", Default: BuilderVarDefault{Value: "tcpSocket"}, AllowedValues: []string{"httpGet", "tcpSocket"}, Versions: ">=0.0.1"},
+			{Name: "PROBEHTTPPATH", Type: "string", Versions: ">=0.0.1", ActiveWhenConstraints: []ActiveWhenConstraint{
+				{VariableName: "PROBETYPE", Value: "httpGet", Condition: EqualTo},
+			}},
+			{Name: "ENABLEWORKLOADIDENTITY", Type: "bool", Default: BuilderVarDefault{Value: "false"}, Versions: ">=0.0.2"},
+			{Name: "SERVICEACCOUNT", Type: "string", Versions: ">=0.0.2", ActiveWhenConstraints: []ActiveWhenConstraint{
+				{VariableName: "ENABLEWORKLOADIDENTITY", Value: "false", Condition: NotEqualTo},
+			}},
+		},
+	}
+
+	schema, err := draftConfig.JSONSchema("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "object" || schema.Title != "deployment-test" || *schema.AdditionalProperties {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+	if len(schema.Properties) != 6 {
+		t.Errorf("got %d properties, want 6", len(schema.Properties))
+	}
+	if !slices.Equal(schema.Required, []string{"APPNAME"}) {
+		t.Errorf("got required: %v, want: [APPNAME]", schema.Required)
+	}
+
+	replicaCount := schema.Properties["REPLICACOUNT"]
+	if replicaCount.Type != "integer" || replicaCount.Default != 1 || !reflect.DeepEqual(replicaCount.Examples, []any{1, 3}) {
+		t.Errorf("unexpected REPLICACOUNT schema: %+v", replicaCount)
+	}
+	if probeType := schema.Properties["PROBETYPE"]; !reflect.DeepEqual(probeType.Enum, []any{"httpGet", "tcpSocket"}) {
+		t.Errorf("unexpected PROBETYPE enum: %v", probeType.Enum)
+	}
+	if identity := schema.Properties["ENABLEWORKLOADIDENTITY"]; identity.Type != "boolean" || identity.Default != false || identity.Versions != ">=0.0.2" {
+		t.Errorf("unexpected ENABLEWORKLOADIDENTITY schema: %+v", identity)
+	}
+
+	if len(schema.AllOf) != 2 {
+		t.Fatalf("got %d conditions, want 2", len(schema.AllOf))
+	}
+	// the default of PROBETYPE does not activate PROBEHTTPPATH, so PROBETYPE has to be given
+	probePath := schema.AllOf[0]
+	if probePath.If.Properties["PROBETYPE"].Const != "httpGet" || !slices.Equal(probePath.If.Required, []string{"PROBETYPE"}) {
+		t.Errorf("unexpected PROBEHTTPPATH condition: %+v", probePath.If)
+	}
+	if !slices.Equal(probePath.Then.Required, []string{"PROBEHTTPPATH"}) || !slices.Equal(probePath.Else.Not.Required, []string{"PROBEHTTPPATH"}) {
+		t.Errorf("unexpected PROBEHTTPPATH branches: %+v, %+v", probePath.Then, probePath.Else)
+	}
+	serviceAccount := schema.AllOf[1]
+	if serviceAccount.If.Properties["ENABLEWORKLOADIDENTITY"].Not.Const != false || len(serviceAccount.If.Required) != 1 {
+		t.Errorf("unexpected SERVICEACCOUNT condition: %+v", serviceAccount.If)
+	}
+
+	if _, err = json.Marshal(schema); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJSONSchemaForVersion(t *testing.T) {
+	draftConfig := DraftConfig{
+		Versions: []string{"0.0.1", "0.0.2"},
+		Variables: []*BuilderVar{
+			{Name: "var1", Versions: ">=0.0.1"},
+			{Name: "var2", Versions: ">=0.0.2"},
+		},
+	}
+
+	schema, err := draftConfig.JSONSchema("0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.Properties["var2"]; ok || len(schema.Properties) != 1 {
+		t.Errorf("got properties %v, want only var1", schema.Properties)
+	}
+
+	if _, err = draftConfig.JSONSchema("0.0.3"); err == nil {
+		t.Error("expected an error for a version outside of the valid versions")
+	}
+	if _, err = draftConfig.JSONSchema("latest"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
+
+func TestJSONSchemaInvalidValues(t *testing.T) {
+	tests := []struct {
+		testName string
+		variable *BuilderVar
+	}{
+		{"invalidDefault", &BuilderVar{Name: "var1", Type: "int", Default: BuilderVarDefault{Value: "two"}}},
+		{"invalidAllowedValue", &BuilderVar{Name: "var1", Type: "bool", AllowedValues: []string{"yes"}}},
+		{"invalidExampleValue", &BuilderVar{Name: "var1", Type: "float", ExampleValues: []string{"half"}}},
+		{"unknownType", &BuilderVar{Name: "var1", Type: "integer"}},
+		{"unknownActiveWhenVariable", &BuilderVar{Name: "var1", ActiveWhenConstraints: []ActiveWhenConstraint{
+			{VariableName: "var2", Value: "true", Condition: EqualTo},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			draftConfig := DraftConfig{Variables: []*BuilderVar{tt.variable}}
+			if _, err := draftConfig.JSONSchema(""); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
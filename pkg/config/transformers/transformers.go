@@ -6,11 +6,19 @@ import (
 )
 
 func GetTransformer(variableKind string) func(string) (any, error) {
+	if transformer, ok := LookupTransformer(variableKind); ok {
+		return transformer
+	}
+	return DefaultTransformer
+}
+
+// LookupTransformer returns the transformer for a variable kind, reporting whether the kind has one
+func LookupTransformer(variableKind string) (func(string) (any, error), bool) {
 	switch variableKind {
 	case "envVarMap":
-		return EnvironmentVariableMapTransformer
+		return EnvironmentVariableMapTransformer, true
 	default:
-		return DefaultTransformer
+		return nil, false
 	}
 }
 
@@ -10,6 +10,15 @@ func TestGetTransformer(t *testing.T) {
 	assert.NotNil(t, GetTransformer("NonExistentKind"))
 }
 
+func TestLookupTransformer(t *testing.T) {
+	transformer, ok := LookupTransformer("envVarMap")
+	assert.True(t, ok)
+	assert.NotNil(t, transformer)
+
+	_, ok = LookupTransformer("NonExistentKind")
+	assert.False(t, ok)
+}
+
 func TestDefaultTransformer(t *testing.T) {
 	res, err := DefaultTransformer("test")
 	assert.Nil(t, err)
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Variable types a template can declare for a variable
+const (
+	VariableTypeString = "string"
+	VariableTypeInt    = "int"
+	VariableTypeFloat  = "float"
+	VariableTypeBool   = "bool"
+	VariableTypeList   = "list"
+	VariableTypeMap    = "map"
+	VariableTypeObject = "object"
+)
+
+// VariableTypes lists the supported variable types
+var VariableTypes = []string{
+	VariableTypeString,
+	VariableTypeInt,
+	VariableTypeFloat,
+	VariableTypeBool,
+	VariableTypeList,
+	VariableTypeMap,
+	VariableTypeObject,
+}
+
+// ParseVariableValue parses the string form of a variable value into the Go value of the variable type: a string,
+// an int, a json.Number keeping the literal text of a float, a bool, a []any for a JSON array, a map[string]any for a
+// JSON object, or any JSON value for an object. Variables without a type are strings.
+func ParseVariableValue(variableType, value string) (any, error) {
+	switch variableType {
+	case "", VariableTypeString:
+		return value, nil
+	case VariableTypeInt:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an int", value)
+		}
+		return i, nil
+	case VariableTypeFloat:
+		// a version like 5.0 renders as written rather than as 5
+		if _, err := strconv.ParseFloat(value, 64); err != nil || !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("%q is not a float", value)
+		}
+		return json.Number(value), nil
+	case VariableTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a bool", value)
+		}
+		return b, nil
+	case VariableTypeList:
+		var list []any
+		if err := json.Unmarshal([]byte(value), &list); err != nil || list == nil {
+			return nil, fmt.Errorf("%q is not a JSON array", value)
+		}
+		return list, nil
+	case VariableTypeMap:
+		var m map[string]any
+		if err := json.Unmarshal([]byte(value), &m); err != nil || m == nil {
+			return nil, fmt.Errorf("%q is not a JSON object", value)
+		}
+		return m, nil
+	case VariableTypeObject:
+		var object any
+		if err := json.Unmarshal([]byte(value), &object); err != nil {
+			return nil, fmt.Errorf("%q is not a JSON value", value)
+		}
+		return object, nil
+	default:
+		return nil, fmt.Errorf("unknown variable type %s", variableType)
+	}
+}
+
+// comparableValue returns the form of a value activeWhen constraints compare, the canonical form for a bool so that
+// True and 1 match true
+func comparableValue(variableType, value string) string {
+	if variableType == VariableTypeBool {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return strconv.FormatBool(b)
+		}
+	}
+	return value
+}
+
+// typedValue is the value of a variable parsed as its declared type, with the type and value it was parsed from
+type typedValue struct {
+	variableType string
+	raw          string
+	value        any
+}
+
+// TypedValue returns the value of the variable parsed as its declared type. The parsed value is kept on the variable
+// until its value or type changes.
+func (bv *BuilderVar) TypedValue() (any, error) {
+	if bv.typed != nil && bv.typed.variableType == bv.Type && bv.typed.raw == bv.Value {
+		return bv.typed.value, nil
+	}
+	value, err := ParseVariableValue(bv.Type, bv.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s variable %s: %w", bv.Type, bv.Name, err)
+	}
+	bv.typed = &typedValue{variableType: bv.Type, raw: bv.Value, value: value}
+	return value, nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseVariableValue(t *testing.T) {
+	tests := []struct {
+		variableType string
+		value        string
+		want         any
+		wantErr      bool
+	}{
+		{variableType: "", value: "anything", want: "anything"},
+		{variableType: "string", value: "80", want: "80"},
+		{variableType: "int", value: "80", want: 80},
+		{variableType: "int", value: "two", wantErr: true},
+		{variableType: "int", value: "1.5", wantErr: true},
+		{variableType: "float", value: "0.5", want: json.Number("0.5")},
+		{variableType: "float", value: "5.0", want: json.Number("5.0")},
+		{variableType: "float", value: "half", wantErr: true},
+		{variableType: "float", value: "NaN", wantErr: true},
+		{variableType: "bool", value: "true", want: true},
+		{variableType: "bool", value: "false", want: false},
+		{variableType: "bool", value: "True", want: true},
+		{variableType: "bool", value: "yes", wantErr: true},
+		{variableType: "list", value: `["a", 1]`, want: []any{"a", float64(1)}},
+		{variableType: "list", value: `{"a": 1}`, wantErr: true},
+		{variableType: "list", value: "null", wantErr: true},
+		{variableType: "map", value: `{"a": "b"}`, want: map[string]any{"a": "b"}},
+		{variableType: "map", value: `["a"]`, wantErr: true},
+		{variableType: "object", value: `{"a": ["b"]}`, want: map[string]any{"a": []any{"b"}}},
+		{variableType: "object", value: "not json", wantErr: true},
+		{variableType: "integer", value: "80", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVariableValue(tt.variableType, tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expected an error parsing %q as %s, got: %#v", tt.value, tt.variableType, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got: %#v, want: %#v, parsing %q as %s", got, tt.want, tt.value, tt.variableType)
+		}
+	}
+}
+
+func TestTypedValue(t *testing.T) {
+	variable := &BuilderVar{Name: "REPLICACOUNT", Type: "int", Value: "3"}
+	if got, err := variable.TypedValue(); err != nil || got != 3 {
+		t.Errorf("got: %#v, %v, want: 3", got, err)
+	}
+	// the parsed value follows changes to the value
+	variable.Value = "4"
+	if got, err := variable.TypedValue(); err != nil || got != 4 {
+		t.Errorf("got: %#v, %v, want: 4", got, err)
+	}
+	variable.Value = "four"
+	if _, err := variable.TypedValue(); err == nil {
+		t.Error("expected an error parsing four as an int")
+	}
+}
+
+func TestCheckActiveWhenConstraintBool(t *testing.T) {
+	draftConfig := DraftConfig{
+		Variables: []*BuilderVar{
+			{Name: "ENABLED", Type: "bool", Value: "True"},
+			{Name: "HOST", ActiveWhenConstraints: []ActiveWhenConstraint{{VariableName: "ENABLED", Value: "true", Condition: EqualTo}}},
+		},
+	}
+	host, _ := draftConfig.GetVariable("HOST")
+	if active, err := draftConfig.CheckActiveWhenConstraint(host); err != nil || !active {
+		t.Errorf("got: %v, %v, want HOST active when ENABLED is True", active, err)
+	}
+}
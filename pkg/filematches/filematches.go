@@ -2,6 +2,7 @@ package filematches
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -25,7 +26,6 @@ func (f *FileMatches) findDeploymentFiles(dest string) error {
 
 func (f *FileMatches) walkFunc(path string, info os.FileInfo, err error) error {
 	if err != nil {
-		log.Fatal(err)
 		return err
 	}
 	if info.IsDir() {
@@ -34,45 +34,51 @@ func (f *FileMatches) walkFunc(path string, info os.FileInfo, err error) error {
 	for _, pattern := range f.patterns {
 		if matched, err := filepath.Match(pattern, filepath.Base(path)); err != nil {
 			return err
-		} else if matched && isValidK8sFile(path) {
-			f.deploymentFiles = append(f.deploymentFiles, path)
+		} else if matched {
+			valid, err := isValidK8sFile(path)
+			if err != nil {
+				return err
+			}
+			if valid {
+				f.deploymentFiles = append(f.deploymentFiles, path)
+			}
 		}
 	}
 	return nil
 }
 
 // TODO: maybe generalize this function in the future
-func isValidK8sFile(filePath string) bool {
+func isValidK8sFile(filePath string) (bool, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		log.Fatal(err)
+		return false, fmt.Errorf("opening %s: %w", filePath, err)
 	}
 
 	v, err := validator.New(nil, validator.Opts{Strict: true})
 	if err != nil {
-		log.Fatalf("failed initializing validator: %s", err)
+		return false, fmt.Errorf("failed initializing validator: %w", err)
 	}
 
 	for i, res := range v.Validate(filePath, f) { // A file might contain multiple resources
 		// File starts with ---, the parser assumes a first empty resource
 		if res.Status == validator.Invalid {
 			log.Printf("resource %d in file %s is not valid: %s", i, filePath, res.Err)
-			return false
+			return false, nil
 		}
 		if res.Status == validator.Error {
 			log.Printf("error while processing resource %d in file %s: %s", i, filePath, res.Err)
-			return false
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
 }
 
 func (f *FileMatches) hasDeploymentFiles() bool {
 	return len(f.deploymentFiles) > 0
 }
 
-func createK8sFileMatches(dest string) *FileMatches {
+func createK8sFileMatches(dest string) (*FileMatches, error) {
 	l := &FileMatches{
 		dest:            dest,
 		patterns:        []string{"*.yaml", "*.yml"},
@@ -80,10 +86,10 @@ func createK8sFileMatches(dest string) *FileMatches {
 	}
 	err := l.findDeploymentFiles(dest)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("searching for deployment files: %w", err)
 	}
 
-	return l
+	return l, nil
 }
 
 func SearchDirectory(dest string) (bool, bool, error) {
@@ -100,7 +106,10 @@ func SearchDirectory(dest string) (bool, bool, error) {
 	}
 
 	// recursive directory search for valid yaml files
-	fileMatches := createK8sFileMatches(dest)
+	fileMatches, err := createK8sFileMatches(dest)
+	if err != nil {
+		return false, false, err
+	}
 	_, err = FindDraftDeploymentFiles(dest)
 	hasDeploymentFiles := fileMatches.hasDeploymentFiles() || err == nil
 	return hasDockerFile, hasDeploymentFiles, nil
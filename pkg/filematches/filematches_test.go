@@ -47,11 +47,13 @@ func TestCreateK8sFileMatchesValidFile(t *testing.T) {
 	}
 	file_name := file.Name()
 
-	fileMatches := createK8sFileMatches(dir)
+	fileMatches, err := createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.True(t, fileMatches.hasDeploymentFiles(), "should have valid deployment files")
 
 	os.Remove(file_name)
-	fileMatches = createK8sFileMatches(dir)
+	fileMatches, err = createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.False(t, fileMatches.hasDeploymentFiles(), "should not have valid deployment files")
 }
 
@@ -68,7 +70,8 @@ func TestCreateK8sFileMatchesInvalidFile(t *testing.T) {
 	}
 	file_name := file.Name()
 
-	fileMatches := createK8sFileMatches(dir)
+	fileMatches, err := createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.False(t, fileMatches.hasDeploymentFiles(), "should not have valid deployment files")
 
 	os.Remove(file_name)
@@ -93,11 +96,13 @@ func TestCreateK8sFileMatchesNestedValidFile(t *testing.T) {
 	}
 	file_name := file.Name()
 
-	fileMatches := createK8sFileMatches(dir)
+	fileMatches, err := createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.True(t, fileMatches.hasDeploymentFiles(), "should have valid deployment files")
 
 	os.Remove(file_name)
-	fileMatches = createK8sFileMatches(dir)
+	fileMatches, err = createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.False(t, fileMatches.hasDeploymentFiles(), "should not have valid deployment files")
 }
 
@@ -120,12 +125,21 @@ func TestCreateK8sFileMatchesNestedInvalidFile(t *testing.T) {
 	}
 	file_name := file.Name()
 
-	fileMatches := createK8sFileMatches(dir)
+	fileMatches, err := createK8sFileMatches(dir)
+	assert.Nil(t, err)
 	assert.False(t, fileMatches.hasDeploymentFiles(), "should not have valid deployment files")
 
 	os.Remove(file_name)
 }
 
+func TestCreateK8sFileMatchesMissingDir(t *testing.T) {
+	_, err := createK8sFileMatches("./does-not-exist")
+	assert.NotNil(t, err)
+
+	_, _, err = SearchDirectory("./does-not-exist")
+	assert.NotNil(t, err)
+}
+
 func touchDockerfile(name string) error {
 	file, err := os.OpenFile(name, os.O_RDONLY|os.O_CREATE, 0644)
 	if err != nil {
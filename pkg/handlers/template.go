@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"strings"
-	tmpl "text/template"
 
 	"github.com/Azure/draft/pkg/config"
 	"github.com/Azure/draft/pkg/handlers/variableextractors/defaults"
@@ -23,6 +23,8 @@ type Template struct {
 	src            string
 	dest           string
 	version        string
+	origin         string    // EmbeddedTemplateOrigin or the template directory the template was loaded from
+	parent         *Template // the template this template extends, whose files it inherits
 }
 
 // GetTemplate returns a template by name, version, and destination
@@ -32,6 +34,10 @@ func GetTemplate(name, version, dest string, templateWriter templatewriter.Templ
 		return nil, fmt.Errorf("template not found: %s", name)
 	}
 
+	if template.Config.Abstract {
+		return nil, fmt.Errorf("template %s is abstract and can only be extended", name)
+	}
+
 	template = template.DeepCopy()
 
 	if version == "" {
@@ -108,9 +114,16 @@ func (t *Template) DeepCopy() *Template {
 		src:            t.src,
 		dest:           t.dest,
 		version:        t.version,
+		origin:         t.origin,
+		parent:         t.parent,
 	}
 }
 
+// Origin returns where the template was loaded from, EmbeddedTemplateOrigin or the absolute path of a template directory
+func (t *Template) Origin() string {
+	return t.origin
+}
+
 func (l *Template) ExtractDefaults(lowerLang string, r reporeader.RepoReader) (map[string]string, error) {
 	extractors := []reporeader.VariableExtractor{
 		&defaults.PythonExtractor{},
@@ -140,34 +153,86 @@ func (l *Template) ExtractDefaults(lowerLang string, r reporeader.RepoReader) (m
 	return extractedValues, nil
 }
 
+// templateFile is a file of a template or of one of the templates it extends
+type templateFile struct {
+	files fs.FS
+	path  string
+}
+
+// generateTemplate writes the files of a template and of the templates it extends, the files of a template replacing the
+// ones of its ancestors with the same path
 func generateTemplate(template *Template) error {
-	err := fs.WalkDir(template.templateFiles, template.src, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
-			return template.templateWriter.EnsureDirectory(strings.Replace(path, template.src, template.dest, 1))
-		}
+	var lineage []*Template
+	for t := template; t != nil; t = t.parent {
+		lineage = append([]*Template{t}, lineage...)
+	}
 
-		if strings.EqualFold(d.Name(), "draft.yaml") {
+	var dirs, order []string
+	files := make(map[string]templateFile)
+	for _, t := range lineage {
+		err := fs.WalkDir(t.templateFiles, t.src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel := relativeTemplatePath(t.src, path)
+			if d.IsDir() {
+				if !slices.Contains(dirs, rel) {
+					dirs = append(dirs, rel)
+				}
+				return nil
+			}
+
+			if strings.EqualFold(d.Name(), "draft.yaml") {
+				return nil
+			}
+
+			if _, ok := files[rel]; !ok {
+				order = append(order, rel)
+			}
+			files[rel] = templateFile{files: t.templateFiles, path: path}
 			return nil
+		})
+		if err != nil {
+			return err
 		}
+	}
 
-		if err := writeTemplate(template, path); err != nil {
-			return fmt.Errorf("failed to write template %s: %w", path, err)
+	for _, dir := range dirs {
+		if err := template.templateWriter.EnsureDirectory(filepath.Join(template.dest, dir)); err != nil {
+			return err
 		}
+	}
+	for _, rel := range order {
+		if err := writeTemplate(template, files[rel], rel); err != nil {
+			return fmt.Errorf("failed to write template %s: %w", files[rel].path, err)
+		}
+	}
+	return nil
+}
 
-		return nil
-	})
-
-	return err
+// relativeTemplatePath returns the path of a template file relative to the source directory of its template
+func relativeTemplatePath(src, path string) string {
+	if src == "." {
+		return path
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(path, src), "/")
 }
 
-func writeTemplate(draftTemplate *Template, inputFile string) error {
-	file, err := fs.ReadFile(draftTemplate.templateFiles, inputFile)
+func writeTemplate(draftTemplate *Template, inputFile templateFile, rel string) error {
+	file, err := fs.ReadFile(inputFile.files, inputFile.path)
+	if err != nil {
+		return err
+	}
+
+	// Start from the shared partials so the file can use them with {{ template "name" . }}
+	tmpl, err := templatePartials.Clone()
 	if err != nil {
 		return err
 	}
 
-	// Parse the template file, missingkey=error ensures an error will be returned if any variable is missing during template execution.
-	tmpl, err := tmpl.New("template").Option("missingkey=error").Parse(string(file))
+	// Parse the template file, the clone keeps missingkey=error so execution fails if any variable is missing.
+	tmpl, err = tmpl.New("template").Parse(string(file))
 	if err != nil {
 		return err
 	}
@@ -179,19 +244,19 @@ func writeTemplate(draftTemplate *Template, inputFile string) error {
 		return err
 	}
 
-	if err = draftTemplate.templateWriter.WriteFile(getOutputFileName(draftTemplate, inputFile), buf.Bytes()); err != nil {
+	if err = draftTemplate.templateWriter.WriteFile(getOutputFileName(draftTemplate, rel), buf.Bytes()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func getOutputFileName(draftTemplate *Template, inputFile string) string {
-	outputName := filepath.Clean(strings.Replace(inputFile, draftTemplate.src, draftTemplate.dest, 1))
+func getOutputFileName(draftTemplate *Template, rel string) string {
+	outputName := filepath.Join(draftTemplate.dest, rel)
 
-	fileName := filepath.Base(inputFile)
+	fileName := filepath.Base(rel)
 	if overrideName, ok := draftTemplate.Config.FileNameOverrideMap[fileName]; ok {
-		return strings.Replace(outputName, fileName, overrideName, 1)
+		return filepath.Join(filepath.Dir(outputName), overrideName)
 	}
 
 	return outputName
@@ -3,10 +3,12 @@ package handlers
 import (
 	"fmt"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"strings"
+	tmpl "text/template"
 
 	"github.com/Azure/draft/pkg/config"
 	"github.com/Azure/draft/template"
@@ -16,12 +18,21 @@ import (
 
 var templateConfigs map[string]*Template
 
+// templatePartials holds the {{ define }} blocks of the partials directories, available to every template file
+var templatePartials *tmpl.Template
+
+// partialsGlob matches the partial files of a template file system
+const partialsGlob = "partials/*.tpl"
+
 type TemplateType string
 
 func (t TemplateType) String() string {
 	return string(t)
 }
 
+// EmbeddedTemplateOrigin is the origin of the templates built into draft
+const EmbeddedTemplateOrigin = "embedded"
+
 const (
 	TemplateTypeDeployment TemplateType = "deployment"
 	TemplateTypeDockerfile TemplateType = "dockerfile"
@@ -40,10 +51,11 @@ func GetTemplates() map[string]*Template {
 	return templateConfigs
 }
 
+// GetTemplatesByType returns the templates of a type that can be generated, leaving out abstract templates
 func GetTemplatesByType(templateType TemplateType) map[string]*Template {
 	templates := make(map[string]*Template)
 	for name, template := range templateConfigs {
-		if template.Config.Type == templateType.String() {
+		if template.Config.Type == templateType.String() && !template.Config.Abstract {
 			templates[name] = template
 		}
 	}
@@ -51,18 +63,131 @@ func GetTemplatesByType(templateType TemplateType) map[string]*Template {
 }
 
 func IsValidTemplate(templateName string) bool {
-	_, ok := templateConfigs[strings.ToLower(templateName)]
-	return ok
+	template, ok := templateConfigs[strings.ToLower(templateName)]
+	return ok && !template.Config.Abstract
 }
 
 func loadTemplates() error {
-	templateConfigs = make(map[string]*Template)
-	return fs.WalkDir(template.Templates, ".", func(path string, d fs.DirEntry, err error) error {
+	return LoadTemplateDirs()
+}
+
+// LoadTemplateDirs reloads the embedded templates and adds the draft.yaml based templates found in the given directories.
+// A template shadows the embedded template, and the templates of the directories after its own, with the same templateName.
+// Templates are merged with the template they extend once every directory is loaded, and the partials of a directory
+// replace the partials with the same name like its templates do.
+func LoadTemplateDirs(dirs ...string) error {
+	configs := make(map[string]*Template)
+	if err := loadTemplatesFromFS(configs, template.Templates, EmbeddedTemplateOrigin); err != nil {
+		return err
+	}
+	partials := tmpl.New("partials").Option("missingkey=error")
+	if err := loadPartialsFromFS(partials, template.Templates); err != nil {
+		return err
+	}
+
+	// load the directories last to first so the first directory has the final say
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir, err := filepath.Abs(dirs[i])
+		if err != nil {
+			return fmt.Errorf("invalid template directory %s: %w", dirs[i], err)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("template directory %s is not a directory", dirs[i])
+		}
+		if err = loadTemplatesFromFS(configs, os.DirFS(dir), dir); err != nil {
+			return fmt.Errorf("loading templates from %s: %w", dirs[i], err)
+		}
+		if err = loadPartialsFromFS(partials, os.DirFS(dir)); err != nil {
+			return fmt.Errorf("loading partials from %s: %w", dirs[i], err)
+		}
+	}
+
+	if err := resolveExtends(configs); err != nil {
+		return err
+	}
+	templateConfigs = configs
+	templatePartials = partials
+	return nil
+}
+
+// ReadTemplateDir returns the sorted names of the templates in a directory without loading them, failing where
+// LoadTemplateDirs would fail for the directory
+func ReadTemplateDir(dir string) ([]string, error) {
+	configs := make(map[string]*Template)
+	if err := loadTemplatesFromFS(configs, template.Templates, EmbeddedTemplateOrigin); err != nil {
+		return nil, err
+	}
+	if err := loadTemplatesFromFS(configs, os.DirFS(dir), dir); err != nil {
+		return nil, fmt.Errorf("loading templates from %s: %w", dir, err)
+	}
+	if err := loadPartialsFromFS(tmpl.New("partials"), os.DirFS(dir)); err != nil {
+		return nil, fmt.Errorf("loading partials from %s: %w", dir, err)
+	}
+	// templates of the directory may extend the embedded ones
+	if err := resolveExtends(configs); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for name, t := range configs {
+		if t.origin == dir {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// loadPartialsFromFS parses the partial files of a file system into partials, replacing the blocks defined before
+func loadPartialsFromFS(partials *tmpl.Template, templateFS fs.FS) error {
+	paths, err := fs.Glob(templateFS, partialsGlob)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		content, err := fs.ReadFile(templateFS, path)
+		if err != nil {
+			return err
+		}
+		if _, err = partials.New(path).Parse(string(content)); err != nil {
+			return fmt.Errorf("parsing partial %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// resolveExtends merges every template with the template it extends, after the parent is merged with its own parent,
+// and links the template to its parent for the files it inherits
+func resolveExtends(configs map[string]*Template) error {
+	draftConfigs := make(map[string]*config.DraftConfig, len(configs))
+	for name, t := range configs {
+		draftConfigs[name] = t.Config
+	}
+	if err := config.ResolveExtends(draftConfigs); err != nil {
+		return err
+	}
+
+	for _, t := range configs {
+		if t.Config.Extends != "" {
+			t.parent = configs[strings.ToLower(t.Config.Extends)]
+		}
+	}
+	return nil
+}
+
+// loadTemplatesFromFS adds every template of a file system to configs, replacing the templates of other origins with
+// the same name. Hidden directories, like .git, are skipped.
+func loadTemplatesFromFS(configs map[string]*Template, templateFS fs.FS, origin string) error {
+	loaded := make(map[string]bool)
+	return fs.WalkDir(templateFS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		if d.IsDir() {
+			if path != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
@@ -70,22 +195,29 @@ func loadTemplates() error {
 			return nil
 		}
 
-		draftConfig, err := config.NewConfigFromFS(template.Templates, path)
+		draftConfig, err := config.NewConfigFromFS(templateFS, path)
 		if err != nil {
 			return err
 		}
+		if draftConfig.TemplateName == "" {
+			return fmt.Errorf("template %s has no templateName", path)
+		}
 
-		if _, ok := templateConfigs[strings.ToLower(draftConfig.TemplateName)]; ok {
+		name := strings.ToLower(draftConfig.TemplateName)
+		if loaded[name] {
 			return fmt.Errorf("duplicate template name: %s", draftConfig.TemplateName)
 		}
+		if existing, ok := configs[name]; ok {
+			log.Debugf("template %s from %s shadows the one from %s", draftConfig.TemplateName, origin, existing.origin)
+		}
 
-		newTemplate := &Template{
+		configs[name] = &Template{
 			Config:        draftConfig,
 			src:           sanatizeTemplateSrcDir(path),
-			templateFiles: template.Templates,
+			templateFiles: templateFS,
+			origin:        origin,
 		}
-
-		templateConfigs[strings.ToLower(draftConfig.TemplateName)] = newTemplate
+		loaded[name] = true
 		return nil
 	})
 }
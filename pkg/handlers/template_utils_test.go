@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/templatewriter/writers"
 )
 
 func TestGetTemplate(t *testing.T) {
@@ -18,3 +23,174 @@ func TestLoadTemplates(t *testing.T) {
 	loadedTemplates := GetTemplates()
 	assert.Positive(t, len(loadedTemplates))
 }
+
+// writeTestTemplate writes a dockerfile template with the given name and Dockerfile content to dir
+func writeTestTemplate(t *testing.T, dir, templateName, dockerfile string) {
+	draftYaml := fmt.Sprintf(`templateName: %q
+type: "dockerfile"
+versions: ["0.0.1"]
+defaultVersion: "0.0.1"
+variables:
+  - name: "PORT"
+    type: "int"
+    kind: "port"
+    default:
+      value: "80"
+    versions: ">=0.0.1"
+`, templateName)
+	assert.Nil(t, os.MkdirAll(dir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "draft.yaml"), []byte(draftYaml), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644))
+}
+
+func TestLoadTemplateDirs(t *testing.T) {
+	t.Cleanup(func() { assert.Nil(t, loadTemplates()) })
+
+	first := t.TempDir()
+	second := t.TempDir()
+	writeTestTemplate(t, filepath.Join(first, "go"), "dockerfile-go", "FROM first:{{ .Config.GetVariableValue \"PORT\" }}\n")
+	writeTestTemplate(t, filepath.Join(second, "go"), "dockerfile-go", "FROM second\n")
+	writeTestTemplate(t, filepath.Join(second, "mylang"), "dockerfile-mylang", "FROM mylang\n")
+	// hidden directories, like a checkout's .git, are not searched
+	writeTestTemplate(t, filepath.Join(second, ".git", "go"), "dockerfile-go", "FROM hidden\n")
+
+	assert.Nil(t, LoadTemplateDirs(first, second))
+
+	// the first directory shadows the second one and the embedded template
+	goTemplate, err := GetTemplate("dockerfile-go", "", ".", &writers.FileMapWriter{FileMap: map[string][]byte{}})
+	assert.Nil(t, err)
+	assert.Equal(t, first, goTemplate.Origin())
+	fileMap := map[string][]byte{}
+	goTemplate.templateWriter = &writers.FileMapWriter{FileMap: fileMap}
+	assert.Nil(t, goTemplate.Generate())
+	assert.Equal(t, "FROM first:80\n", string(fileMap["Dockerfile"]))
+
+	assert.True(t, IsValidTemplate("dockerfile-mylang"))
+	assert.Equal(t, second, GetTemplates()["dockerfile-mylang"].Origin())
+	assert.Equal(t, EmbeddedTemplateOrigin, GetTemplates()["deployment-manifests"].Origin())
+
+	// reloading without directories restores the embedded templates
+	assert.Nil(t, LoadTemplateDirs())
+	assert.Equal(t, EmbeddedTemplateOrigin, GetTemplates()["dockerfile-go"].Origin())
+	assert.False(t, IsValidTemplate("dockerfile-mylang"))
+}
+
+func TestLoadTemplateDirsInvalid(t *testing.T) {
+	t.Cleanup(func() { assert.Nil(t, loadTemplates()) })
+
+	assert.NotNil(t, LoadTemplateDirs(filepath.Join(t.TempDir(), "missing")))
+
+	duplicates := t.TempDir()
+	writeTestTemplate(t, filepath.Join(duplicates, "a"), "dockerfile-custom", "FROM a\n")
+	writeTestTemplate(t, filepath.Join(duplicates, "b"), "dockerfile-custom", "FROM b\n")
+	assert.ErrorContains(t, LoadTemplateDirs(duplicates), "duplicate template name")
+
+	unnamed := t.TempDir()
+	writeTestTemplate(t, unnamed, "", "FROM unnamed\n")
+	assert.ErrorContains(t, LoadTemplateDirs(unnamed), "no templateName")
+
+	// a failed load keeps the previously loaded templates
+	assert.True(t, IsValidTemplate("dockerfile-go"))
+}
+
+func TestTemplateExtends(t *testing.T) {
+	t.Cleanup(func() { assert.Nil(t, loadTemplates()) })
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"base/draft.yaml": `templateName: "dockerfile-custombase"
+abstract: true
+type: "dockerfile"
+versions: ["0.0.1"]
+defaultVersion: "0.0.1"
+variables:
+  - name: "PORT"
+    type: "int"
+    kind: "port"
+    default:
+      value: "80"
+    versions: ">=0.0.1"
+`,
+		"base/Dockerfile": "FROM base\n",
+		"base/README.md":  `{{ template "custom.port" . }}`,
+		"child/draft.yaml": `templateName: "dockerfile-child"
+extends: "dockerfile-custombase"
+variables:
+  - name: "PORT"
+    default:
+      value: "8080"
+`,
+		"child/Dockerfile":    "FROM child\n",
+		"partials/custom.tpl": `{{ define "custom.port" }}port {{ .Config.GetVariableValue "PORT" }}{{ end }}`,
+		// an overlay of the embedded base changes every embedded dockerfile template
+		"basedockerfile/draft.yaml": `templateName: "base-dockerfile"
+abstract: true
+type: "dockerfile"
+versions: ["0.0.1"]
+defaultVersion: "0.0.1"
+variables:
+  - name: "PORT"
+    type: "int"
+    kind: "port"
+    default:
+      value: "3000"
+    versions: ">=0.0.1"
+`,
+	}
+	for name, content := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	assert.Nil(t, LoadTemplateDirs(dir))
+
+	// abstract templates can only be extended
+	assert.False(t, IsValidTemplate("dockerfile-custombase"))
+	assert.NotContains(t, GetTemplatesByType(TemplateTypeDockerfile), "dockerfile-custombase")
+	_, err := GetTemplate("dockerfile-custombase", "", ".", &writers.FileMapWriter{})
+	assert.ErrorContains(t, err, "abstract")
+
+	fileMap := map[string][]byte{}
+	child, err := GetTemplate("dockerfile-child", "", "out", &writers.FileMapWriter{FileMap: fileMap})
+	assert.Nil(t, err)
+	assert.Equal(t, "dockerfile", child.Config.Type)
+	port, err := child.Config.GetVariable("PORT")
+	assert.Nil(t, err)
+	assert.Equal(t, "port", port.Kind)
+	assert.Nil(t, child.Generate())
+	// the child replaces the Dockerfile of its parent and inherits the README rendered with a partial
+	assert.Equal(t, "FROM child\n", string(fileMap[filepath.Join("out", "Dockerfile")]))
+	assert.Equal(t, "port 8080", string(fileMap[filepath.Join("out", "README.md")]))
+
+	goTemplate, err := GetTemplate("dockerfile-go", "", ".", &writers.FileMapWriter{})
+	assert.Nil(t, err)
+	port, err = goTemplate.Config.GetVariable("PORT")
+	assert.Nil(t, err)
+	assert.Equal(t, "3000", port.Default.Value)
+	version, err := goTemplate.Config.GetVariable("VERSION")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.23", version.Default.Value)
+}
+
+func TestTemplateExtendsInvalid(t *testing.T) {
+	t.Cleanup(func() { assert.Nil(t, loadTemplates()) })
+
+	writeExtendingTemplate := func(dir, name, extends string) {
+		draftYaml := fmt.Sprintf("templateName: %q\nextends: %q\ntype: \"dockerfile\"\nversions: [\"0.0.1\"]\ndefaultVersion: \"0.0.1\"\n", name, extends)
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name, "draft.yaml"), []byte(draftYaml), 0644))
+	}
+
+	unknown := t.TempDir()
+	writeExtendingTemplate(unknown, "dockerfile-orphan", "dockerfile-missing")
+	assert.ErrorContains(t, LoadTemplateDirs(unknown), "extends unknown template dockerfile-missing")
+
+	cycle := t.TempDir()
+	writeExtendingTemplate(cycle, "dockerfile-a", "dockerfile-b")
+	writeExtendingTemplate(cycle, "dockerfile-b", "dockerfile-a")
+	assert.ErrorContains(t, LoadTemplateDirs(cycle), "cycle")
+
+	partials := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(partials, "partials"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(partials, "partials", "broken.tpl"), []byte(`{{ define "broken" }}`), 0644))
+	assert.ErrorContains(t, LoadTemplateDirs(partials), "parsing partial")
+}
@@ -38,7 +38,7 @@ func RunTemplateTest(t *testing.T, testInput TestInput) {
 		assert.NotNil(t, template)
 
 		for k, v := range testInput.VarMap {
-			template.Config.SetVariable(k, v)
+			assert.Nil(t, template.Config.SetTypedVariable(k, v))
 		}
 
 		for k, v := range testInput.Validators {
@@ -0,0 +1,33 @@
+package templatetests
+
+import (
+	"testing"
+
+	"github.com/Azure/draft/pkg/templatewriter/writers"
+)
+
+func TestAzureWorkflowHelmTemplates(t *testing.T) {
+	tests := []TestInput{
+		{
+			Name:            "valid azpipeline helm deployment",
+			TemplateName:    "azure-pipeline-helm",
+			FixturesBaseDir: "../../fixtures/workflows/azurepipelines/helm",
+			Version:         "0.0.1",
+			Dest:            ".",
+			TemplateWriter:  &writers.FileMapWriter{},
+			VarMap: map[string]string{
+				"ARMSERVICECONNECTION":   "testserviceconnection",
+				"AZURECONTAINERREGISTRY": "myacr.acr.io",
+				"CONTAINERNAME":          "myapp",
+				"CLUSTERRESOURCEGROUP":   "myrg",
+				"ACRRESOURCEGROUP":       "myrg",
+				"CLUSTERNAME":            "testcluster",
+				"CLUSTERRESOURCETYPE":    "Microsoft.ContainerService/managedClusters",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		RunTemplateTest(t, test)
+	}
+}
@@ -0,0 +1,99 @@
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ' unchanged, '-' removed, '+' added
+	text string
+	a, b int // the index of the line in before and after, counting the lines seen so far on each side
+}
+
+// Diff returns a unified diff of the before and after contents of the named file, or nil when they are equal
+func Diff(name string, before, after []byte) []byte {
+	beforeLines := splitLines(before)
+	afterLines := splitLines(after)
+	matches := matchLines(beforeLines, afterLines)
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(beforeLines) || j < len(afterLines) {
+		switch {
+		case i < len(beforeLines) && matches[i] == j:
+			lines = append(lines, diffLine{op: ' ', text: beforeLines[i], a: i, b: j})
+			i, j = i+1, j+1
+		case i < len(beforeLines) && matches[i] < 0:
+			lines = append(lines, diffLine{op: '-', text: beforeLines[i], a: i, b: j})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: afterLines[j], a: i, b: j})
+			j++
+		}
+	}
+
+	var out bytes.Buffer
+	for start := 0; start < len(lines); {
+		// find the next change and extend its hunk while changes are within reach of the context
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for k := first + 1; k < len(lines) && k <= last+2*diffContext; k++ {
+			if lines[k].op != ' ' {
+				last = k
+			}
+		}
+
+		hunkStart := max(first-diffContext, start)
+		hunkEnd := min(last+diffContext+1, len(lines))
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+		}
+		writeDiffHunk(&out, lines[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+
+	if out.Len() == 0 {
+		return nil
+	}
+	return out.Bytes()
+}
+
+func writeDiffHunk(out *bytes.Buffer, hunk []diffLine) {
+	beforeCount, afterCount := 0, 0
+	for _, line := range hunk {
+		if line.op != '+' {
+			beforeCount++
+		}
+		if line.op != '-' {
+			afterCount++
+		}
+	}
+	// empty ranges start at the line before them
+	beforeStart, afterStart := hunk[0].a+1, hunk[0].b+1
+	if beforeCount == 0 {
+		beforeStart--
+	}
+	if afterCount == 0 {
+		afterStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", beforeStart, beforeCount, afterStart, afterCount)
+
+	for _, line := range hunk {
+		out.WriteByte(line.op)
+		out.WriteString(line.text)
+		if !strings.HasSuffix(line.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
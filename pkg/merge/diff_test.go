@@ -0,0 +1,53 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "no changes",
+			before:   "a\nb\nc\n",
+			after:    "a\nb\nc\n",
+			expected: "",
+		},
+		{
+			name:     "changed line",
+			before:   "a\nb\nc\n",
+			after:    "a\nB\nc\n",
+			expected: "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "added lines only show context",
+			before:   "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:    "1\n2\n3\n4\n5\nnew\n6\n7\n8\n",
+			expected: "--- f\n+++ f\n@@ -3,6 +3,7 @@\n 3\n 4\n 5\n+new\n 6\n 7\n 8\n",
+		},
+		{
+			name:     "distant changes use separate hunks",
+			before:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- f\n+++ f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name:     "everything added",
+			before:   "",
+			after:    "a\n",
+			expected: "--- f\n+++ f\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(Diff("f", []byte(tt.before), []byte(tt.after))))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package merge
+
+import (
+	"bytes"
+)
+
+const (
+	ConflictStartMarker = "<<<<<<< current"
+	ConflictBaseMarker  = "||||||| generated"
+	ConflictSepMarker   = "======="
+	ConflictEndMarker   = ">>>>>>> draft"
+)
+
+// ThreeWay performs a line based three-way merge of the current file contents and the newly
+// generated contents, using the previously generated contents as the common ancestor.
+// Hunks changed on only one side are taken from that side. Hunks changed differently on both
+// sides are written with diff3 style conflict markers and counted in the returned conflicts.
+func ThreeWay(base, current, generated []byte) (merged []byte, conflicts int) {
+	baseLines := splitLines(base)
+	currentLines := splitLines(current)
+	generatedLines := splitLines(generated)
+
+	currentMatches := matchLines(baseLines, currentLines)
+	generatedMatches := matchLines(baseLines, generatedLines)
+
+	var out bytes.Buffer
+	i, c, g := 0, 0, 0
+	for i < len(baseLines) || c < len(currentLines) || g < len(generatedLines) {
+		// stable line, unchanged on every side
+		if i < len(baseLines) && currentMatches[i] == c && generatedMatches[i] == g {
+			out.WriteString(baseLines[i])
+			i, c, g = i+1, c+1, g+1
+			continue
+		}
+
+		// find the next base line that is still present on both sides
+		nextBase, nextCurrent, nextGenerated := len(baseLines), len(currentLines), len(generatedLines)
+		for j := i; j < len(baseLines); j++ {
+			if currentMatches[j] >= c && generatedMatches[j] >= g {
+				nextBase, nextCurrent, nextGenerated = j, currentMatches[j], generatedMatches[j]
+				break
+			}
+		}
+
+		baseHunk := baseLines[i:nextBase]
+		currentHunk := currentLines[c:nextCurrent]
+		generatedHunk := generatedLines[g:nextGenerated]
+
+		switch {
+		case equalLines(currentHunk, baseHunk):
+			writeLines(&out, generatedHunk)
+		case equalLines(generatedHunk, baseHunk), equalLines(currentHunk, generatedHunk):
+			writeLines(&out, currentHunk)
+		default:
+			conflicts++
+			writeConflict(&out, baseHunk, currentHunk, generatedHunk)
+		}
+
+		i, c, g = nextBase, nextCurrent, nextGenerated
+	}
+
+	return out.Bytes(), conflicts
+}
+
+// matchLines returns, for every line of a, the index of the line in b it is matched to by the
+// longest common subsequence of both, or -1 when the line is not part of it
+func matchLines(a, b []string) []int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches[i] = j
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		if end < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:end+1]))
+		content = content[end+1:]
+	}
+	return lines
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+func writeConflict(out *bytes.Buffer, base, current, generated []string) {
+	// conflict markers must start on their own line
+	if out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n' {
+		out.WriteByte('\n')
+	}
+
+	writeMarker(out, ConflictStartMarker)
+	writeHunk(out, current)
+	writeMarker(out, ConflictBaseMarker)
+	writeHunk(out, base)
+	writeMarker(out, ConflictSepMarker)
+	writeHunk(out, generated)
+	writeMarker(out, ConflictEndMarker)
+}
+
+func writeHunk(out *bytes.Buffer, lines []string) {
+	writeLines(out, lines)
+	if len(lines) > 0 && lines[len(lines)-1][len(lines[len(lines)-1])-1] != '\n' {
+		out.WriteByte('\n')
+	}
+}
+
+func writeMarker(out *bytes.Buffer, marker string) {
+	out.WriteString(marker)
+	out.WriteByte('\n')
+}
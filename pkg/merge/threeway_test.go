@@ -0,0 +1,86 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreeWay(t *testing.T) {
+	tests := []struct {
+		name              string
+		base              string
+		current           string
+		generated         string
+		expected          string
+		expectedConflicts int
+	}{
+		{
+			name:      "no changes",
+			base:      "a\nb\nc\n",
+			current:   "a\nb\nc\n",
+			generated: "a\nb\nc\n",
+			expected:  "a\nb\nc\n",
+		},
+		{
+			name:      "only generated changed",
+			base:      "a\nb\nc\n",
+			current:   "a\nb\nc\n",
+			generated: "a\nB\nc\n",
+			expected:  "a\nB\nc\n",
+		},
+		{
+			name:      "only current changed",
+			base:      "a\nb\nc\n",
+			current:   "a\nb\nc\nd\n",
+			generated: "a\nb\nc\n",
+			expected:  "a\nb\nc\nd\n",
+		},
+		{
+			name:      "non overlapping changes on both sides",
+			base:      "a\nb\nc\nd\ne\n",
+			current:   "a\nB\nc\nd\ne\n",
+			generated: "a\nb\nc\nd\nE\n",
+			expected:  "a\nB\nc\nd\nE\n",
+		},
+		{
+			name:      "identical changes on both sides",
+			base:      "a\nb\nc\n",
+			current:   "a\nx\nc\n",
+			generated: "a\nx\nc\n",
+			expected:  "a\nx\nc\n",
+		},
+		{
+			name:              "conflicting changes",
+			base:              "a\nb\nc\n",
+			current:           "a\nmine\nc\n",
+			generated:         "a\ntheirs\nc\n",
+			expected:          "a\n<<<<<<< current\nmine\n||||||| generated\nb\n=======\ntheirs\n>>>>>>> draft\nc\n",
+			expectedConflicts: 1,
+		},
+		{
+			name:              "conflicting appends to a final line without trailing newline",
+			base:              "a",
+			current:           "a\nmine",
+			generated:         "a\ntheirs",
+			expected:          "<<<<<<< current\na\nmine\n||||||| generated\na\n=======\na\ntheirs\n>>>>>>> draft\n",
+			expectedConflicts: 1,
+		},
+		{
+			name:              "no base",
+			base:              "",
+			current:           "mine\n",
+			generated:         "theirs\n",
+			expected:          "<<<<<<< current\nmine\n||||||| generated\n=======\ntheirs\n>>>>>>> draft\n",
+			expectedConflicts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflicts := ThreeWay([]byte(test.base), []byte(test.current), []byte(test.generated))
+			assert.Equal(t, test.expected, string(merged))
+			assert.Equal(t, test.expectedConflicts, conflicts)
+		})
+	}
+}
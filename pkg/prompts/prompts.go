@@ -73,7 +73,7 @@ func RunPromptsFromConfigWithSkipsIO(draftConfig *config.DraftConfig, Stdin io.R
 		} else {
 			defaultValue := GetVariableDefaultValue(draftConfig, variable)
 
-			stringInput, err := RunDefaultableStringPrompt(defaultValue, variable, nil, Stdin, Stdout)
+			stringInput, err := RunDefaultableStringPrompt(defaultValue, variable, variableTypeValidator(variable), Stdin, Stdout)
 			if err != nil {
 				return err
 			}
@@ -140,6 +140,17 @@ func NoBlankStringValidator(s string) error {
 	return nil
 }
 
+// variableTypeValidator returns a string validator that only allows non-blank values of the declared type of a variable
+func variableTypeValidator(variable *config.BuilderVar) func(string) error {
+	return func(s string) error {
+		if err := NoBlankStringValidator(s); err != nil {
+			return err
+		}
+		_, err := config.ParseVariableValue(variable.Type, s)
+		return err
+	}
+}
+
 // Validator for App name
 func appNameValidator(name string) error {
 	errors := validation.IsDNS1123Label(name)
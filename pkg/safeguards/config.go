@@ -0,0 +1,103 @@
+package safeguards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// LoadConfig reads a safeguards configuration file, returning an empty configuration when the file does not exist
+func LoadConfig(configPath string) (types.SafeguardsConfig, error) {
+	var config types.SafeguardsConfig
+
+	content, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("reading safeguards config: %w", err)
+	}
+
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("parsing safeguards config %s: %w", configPath, err)
+	}
+
+	return config, nil
+}
+
+// validateConfig rejects configurations of unknown safeguards and versions and invalid severities
+func validateConfig(config types.SafeguardsConfig, isKnown func(name string) bool) error {
+	if _, err := selectVersion("", config.Version); err != nil {
+		return err
+	}
+	for _, name := range config.Exclude {
+		if !isKnown(name) {
+			return fmt.Errorf("cannot exclude unknown safeguard %q", name)
+		}
+	}
+	for name, cc := range config.Constraints {
+		if !isKnown(name) {
+			return fmt.Errorf("cannot configure unknown safeguard %q", name)
+		}
+		if cc.Severity != "" && cc.Severity != types.SeverityError && cc.Severity != types.SeverityWarning {
+			return fmt.Errorf("invalid severity %q for safeguard %s, must be %s or %s", cc.Severity, name, types.SeverityError, types.SeverityWarning)
+		}
+	}
+	return nil
+}
+
+// enabledSafeguards returns the safeguards to evaluate once the configured exclusions are removed
+func enabledSafeguards(config types.SafeguardsConfig, safeguards []types.Safeguard) []types.Safeguard {
+	enabled := make([]types.Safeguard, 0, len(safeguards))
+	for _, sg := range safeguards {
+		if slices.Contains(config.Exclude, sg.Name) {
+			continue
+		}
+		enabled = append(enabled, sg)
+	}
+	return enabled
+}
+
+// applyConstraintParameters merges the configured parameter overrides of a safeguard into its constraint
+func applyConstraintParameters(config types.SafeguardsConfig, name string, constraint *unstructured.Unstructured) error {
+	for key, value := range config.Constraints[name].Parameters {
+		parameter, err := toJSONValue(value)
+		if err != nil {
+			return fmt.Errorf("overriding parameter %s of safeguard %s: %w", key, name, err)
+		}
+		if err = unstructured.SetNestedField(constraint.Object, parameter, "spec", "parameters", key); err != nil {
+			return fmt.Errorf("overriding parameter %s of safeguard %s: %w", key, name, err)
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts a parameter to the JSON types unstructured objects hold, so parameters set in code as an int,
+// a []string or a map[string]string are accepted like the float64 and []interface{} of parameters read from YAML
+func toJSONValue(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var jsonValue interface{}
+	if err = json.Unmarshal(content, &jsonValue); err != nil {
+		return nil, err
+	}
+	return jsonValue, nil
+}
+
+// getSeverity returns the configured severity of a safeguard, defaulting to error
+func getSeverity(config types.SafeguardsConfig, name string) string {
+	if severity := config.Constraints[name].Severity; severity != "" {
+		return severity
+	}
+	return types.SeverityError
+}
@@ -0,0 +1,142 @@
+package safeguards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const allErrorManifestPath = "tests/all/error/all-error-manifest-1.yaml"
+
+func TestLoadConfig(t *testing.T) {
+	// missing config files result in an empty configuration
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "safeguards.yaml"))
+	assert.Nil(t, err)
+	assert.Empty(t, config.Exclude)
+
+	configPath := filepath.Join(t.TempDir(), "safeguards.yaml")
+	err = os.WriteFile(configPath, []byte(`exclude:
+  - pod-enforce-antiaffinity
+constraints:
+  container-allowed-images:
+    severity: warning
+    parameters:
+      imageRegex: "^myacr.azurecr.io/.+$"
+`), 0644)
+	assert.Nil(t, err)
+
+	config, err = LoadConfig(configPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{types.Constraint_PEA}, config.Exclude)
+	assert.Equal(t, types.SeverityWarning, config.Constraints[types.Constraint_CAI].Severity)
+	assert.Equal(t, "^myacr.azurecr.io/.+$", config.Constraints[types.Constraint_CAI].Parameters["imageRegex"])
+}
+
+func TestNewValidatorConfig(t *testing.T) {
+	configs := []types.SafeguardsConfig{
+		{Exclude: []string{"not-a-safeguard"}},
+		{Constraints: map[string]types.ConstraintConfig{"not-a-safeguard": {Severity: types.SeverityWarning}}},
+		{Constraints: map[string]types.ConstraintConfig{types.Constraint_CEP: {Severity: "info"}}},
+	}
+	for _, config := range configs {
+		_, err := NewValidator(ctx, ValidatorOptions{Config: config})
+		assert.NotNil(t, err)
+	}
+	_, err := NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{Exclude: []string{types.Constraint_CRIP}}})
+	assert.Nil(t, err)
+}
+
+func TestGetManifestResultsWithConfig(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+
+	// validators with different configurations coexist
+	unconfigured, err := NewValidator(ctx, ValidatorOptions{})
+	assert.Nil(t, err)
+	configured, err := NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{
+		Exclude: []string{types.Constraint_CEP},
+		Constraints: map[string]types.ConstraintConfig{
+			types.Constraint_CRL: {Severity: types.SeverityWarning},
+		},
+	}})
+	assert.Nil(t, err)
+	overridden, err := NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{
+		Constraints: map[string]types.ConstraintConfig{
+			types.Constraint_CAI: {Parameters: map[string]interface{}{"imageRegex": "^myacr.azurecr.io/.+$"}},
+		},
+	}})
+	assert.Nil(t, err)
+
+	results, err := unconfigured.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	violated := violatedSafeguards(results)
+	assert.Contains(t, violated, types.Constraint_CEP)
+	for _, r := range results {
+		for _, v := range r.Violations {
+			assert.Equal(t, types.SeverityError, v.Severity)
+		}
+	}
+
+	// excluded safeguards are not evaluated and warnings keep their violations
+	results, err = configured.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.NotContains(t, violatedSafeguards(results), types.Constraint_CEP)
+	for _, r := range results {
+		for _, v := range r.Violations {
+			if v.Constraint == types.Constraint_CRL {
+				assert.Equal(t, types.SeverityWarning, v.Severity)
+			}
+		}
+	}
+
+	// parameter overrides are applied to the constraint
+	results, err = overridden.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, violatedSafeguards(results), types.Constraint_CAI)
+	assert.NotContains(t, violated, types.Constraint_CAI)
+
+	// the package-level GetManifestResults is left unconfigured
+	results, err = GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, violatedSafeguards(results), types.Constraint_CEP)
+}
+
+func TestApplyConstraintParameters(t *testing.T) {
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	config := types.SafeguardsConfig{Constraints: map[string]types.ConstraintConfig{
+		types.Constraint_CAI: {Parameters: map[string]interface{}{
+			"replicas": 2,
+			"images":   []string{"nginx"},
+			"labels":   map[string]string{"app": "web"},
+		}},
+	}}
+
+	// parameters set in code are converted to JSON types rather than panicking
+	assert.Nil(t, applyConstraintParameters(config, types.Constraint_CAI, constraint))
+	parameters, _, _ := unstructured.NestedMap(constraint.Object, "spec", "parameters")
+	assert.Equal(t, map[string]interface{}{
+		"replicas": float64(2),
+		"images":   []interface{}{"nginx"},
+		"labels":   map[string]interface{}{"app": "web"},
+	}, parameters)
+
+	config.Constraints[types.Constraint_CAI] = types.ConstraintConfig{Parameters: map[string]interface{}{"invalid": make(chan int)}}
+	assert.ErrorContains(t, applyConstraintParameters(config, types.Constraint_CAI, constraint), "overriding parameter invalid")
+}
+
+func violatedSafeguards(results []types.ManifestResult) []string {
+	var names []string
+	for _, r := range results {
+		for _, v := range r.Violations {
+			names = append(names, v.Constraint)
+		}
+	}
+	return names
+}
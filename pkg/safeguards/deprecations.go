@@ -0,0 +1,114 @@
+package safeguards
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ghodss/yaml"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+//go:embed deprecated-apis.yaml
+var deprecatedAPIsYAML []byte
+
+// deprecatedAPI is an entry of the embedded deprecation table
+type deprecatedAPI struct {
+	APIVersion   string   `json:"apiVersion"`
+	Kinds        []string `json:"kinds"`
+	DeprecatedIn string   `json:"deprecatedIn"`
+	RemovedIn    string   `json:"removedIn"`
+	Replacement  string   `json:"replacement,omitempty"`
+}
+
+// readDeprecatedAPIs parses the embedded deprecation table
+func readDeprecatedAPIs() ([]deprecatedAPI, error) {
+	var apis []deprecatedAPI
+	if err := yaml.Unmarshal(deprecatedAPIsYAML, &apis); err != nil {
+		return nil, fmt.Errorf("parsing deprecated api table: %w", err)
+	}
+	return apis, nil
+}
+
+// GetDeprecationResults flags the objects of the manifest files whose apiVersion is removed, reported as an error, or
+// deprecated, reported as a warning, as of the target Kubernetes version. Objects can suppress the check with the
+// safeguards-ignore annotation like any safeguard.
+func GetDeprecationResults(manifestFiles []types.ManifestFile, targetVersion string) ([]types.ManifestResult, error) {
+	target, err := semver.NewVersion(targetVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target Kubernetes version %q: %w", targetVersion, err)
+	}
+	apis, err := readDeprecatedAPIs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]types.ManifestResult, 0, len(manifestFiles))
+	for _, m := range manifestFiles {
+		file := m.Source
+		if file == "" {
+			file = m.Name
+		}
+
+		objects, err := types.FileCrawler{}.ReadManifests(m.ManifestContent)
+		if err != nil {
+			return nil, fmt.Errorf("reading objects of %s: %w", file, err)
+		}
+		objectPositions := getObjectPositions(m.ManifestContent)
+
+		var violations []types.Violation
+		for _, o := range objects {
+			api, ok := findDeprecatedAPI(apis, o.GetAPIVersion(), o.GetKind())
+			if !ok {
+				continue
+			}
+
+			violation := types.Violation{
+				Constraint:     types.Constraint_DeprecatedAPI,
+				ConstraintName: types.Constraint_DeprecatedAPI,
+				APIVersion:     o.GetAPIVersion(),
+				Kind:           o.GetKind(),
+				Namespace:      o.GetNamespace(),
+				Name:           o.GetName(),
+				File:           file,
+			}
+			violation.Document = objectPositions[violation.ObjectKey()].document
+			violation.Line = objectPositions[violation.ObjectKey()].line
+
+			replacement := "it has no replacement"
+			if api.Replacement != "" {
+				replacement = fmt.Sprintf("use %s instead", api.Replacement)
+			}
+			switch {
+			case !target.LessThan(semver.MustParse(api.RemovedIn)):
+				violation.Severity = types.SeverityError
+				violation.Message = fmt.Sprintf("%s %s was removed in Kubernetes %s, %s", api.APIVersion, o.GetKind(), api.RemovedIn, replacement)
+			case api.DeprecatedIn != "" && !target.LessThan(semver.MustParse(api.DeprecatedIn)):
+				violation.Severity = types.SeverityWarning
+				violation.Message = fmt.Sprintf("%s %s is deprecated since Kubernetes %s and removed in %s, %s", api.APIVersion, o.GetKind(), api.DeprecatedIn, api.RemovedIn, replacement)
+			default:
+				continue
+			}
+			violations = append(violations, violation)
+		}
+
+		violations, suppressed := suppressViolations(objects, violations)
+		result := types.ManifestResult{Name: m.Name, Source: m.Source, ObjectViolations: make(map[string][]string), Violations: []types.Violation{}, Suppressed: suppressed}
+		result.AddViolations(violations...)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// findDeprecatedAPI returns the deprecation table entry of an apiVersion and kind, if any
+func findDeprecatedAPI(apis []deprecatedAPI, apiVersion, kind string) (deprecatedAPI, bool) {
+	for _, api := range apis {
+		if api.APIVersion == apiVersion && slices.Contains(api.Kinds, kind) {
+			return api, true
+		}
+	}
+	return deprecatedAPI{}, false
+}
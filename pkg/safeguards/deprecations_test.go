@@ -0,0 +1,70 @@
+package safeguards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const deprecatedManifestPath = "tests/deprecated-apis/deprecated-manifest.yaml"
+
+func TestReadDeprecatedAPIs(t *testing.T) {
+	apis, err := readDeprecatedAPIs()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, apis)
+	for _, api := range apis {
+		assert.NotEmpty(t, api.APIVersion)
+		assert.NotEmpty(t, api.Kinds)
+		assert.NotEmpty(t, api.RemovedIn)
+	}
+}
+
+func TestGetDeprecationResults(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(deprecatedManifestPath, opt)
+	assert.Nil(t, err)
+
+	results, err := GetDeprecationResults(manifestFiles, "1.29")
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+
+	violations := results[0].Violations
+	assert.Len(t, violations, 3)
+	assert.Equal(t, types.Violation{
+		Constraint:     types.Constraint_DeprecatedAPI,
+		ConstraintName: types.Constraint_DeprecatedAPI,
+		Severity:       types.SeverityError,
+		APIVersion:     "extensions/v1beta1",
+		Kind:           "Ingress",
+		Namespace:      "prod",
+		Name:           "myapp",
+		Message:        "extensions/v1beta1 Ingress was removed in Kubernetes 1.22, use networking.k8s.io/v1 instead",
+		File:           deprecatedManifestPath,
+		Document:       0,
+		Line:           1,
+	}, violations[0])
+	assert.Equal(t, "CronJob", violations[1].Kind)
+	assert.Equal(t, types.SeverityError, violations[1].Severity)
+	assert.Equal(t, "FlowSchema", violations[2].Kind)
+	assert.Equal(t, types.SeverityWarning, violations[2].Severity)
+	assert.Contains(t, violations[2].Message, "deprecated since Kubernetes 1.29")
+
+	// the annotated PodDisruptionBudget suppresses the check
+	assert.Len(t, results[0].Suppressed, 1)
+	assert.Equal(t, "PodDisruptionBudget", results[0].Suppressed[0].Kind)
+
+	// older clusters only see deprecations
+	results, err = GetDeprecationResults(manifestFiles, "v1.21.3")
+	assert.Nil(t, err)
+	for _, v := range results[0].Violations {
+		assert.Equal(t, types.SeverityWarning, v.Severity)
+		assert.NotEqual(t, "FlowSchema", v.Kind)
+	}
+	assert.Len(t, results[0].Violations, 2)
+
+	_, err = GetDeprecationResults(manifestFiles, "latest")
+	assert.NotNil(t, err)
+}
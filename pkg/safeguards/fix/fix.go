@@ -0,0 +1,221 @@
+package fix
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const (
+	defaultCPURequest    = "100m"
+	defaultMemoryRequest = "128Mi"
+	defaultCPULimit      = "500m"
+	defaultMemoryLimit   = "512Mi"
+
+	antiAffinityTopologyKey = "kubernetes.io/hostname"
+	antiAffinityWeight      = "100"
+	appLabel                = "app"
+)
+
+// probeHandlers are the fields that make a probe satisfy container-enforce-probes
+var probeHandlers = []string{"tcpSocket", "httpGet", "exec", "grpc"}
+
+// fixers patch a Deployment for the violations of one safeguard and describe what they changed
+var fixers = map[string]func(deployment *yaml.RNode) ([]string, error){
+	types.Constraint_CRL: fixResourceLimits,
+	types.Constraint_CEP: fixProbes,
+	types.Constraint_PEA: fixAntiAffinity,
+}
+
+// Fixable reports whether violations of the named safeguard can be fixed automatically
+func Fixable(safeguard string) bool {
+	_, ok := fixers[safeguard]
+	return ok
+}
+
+// Manifest patches the Deployments of a multi-document manifest for their fixable violations. Comments and field order
+// are preserved. It returns the patched manifest and a description of every change, which is empty when nothing changed.
+func Manifest(content []byte, violations []types.Violation) ([]byte, []string, error) {
+	rw := &kio.ByteReadWriter{
+		Reader:            bytes.NewReader(content),
+		PreserveSeqIndent: true,
+	}
+	nodes, err := rw.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	var changes []string
+	for _, node := range nodes {
+		if node.GetKind() != "Deployment" {
+			continue
+		}
+
+		// fix every violated safeguard once per object, in a stable order
+		key := types.ObjectKey(node.GetApiVersion(), node.GetKind(), node.GetNamespace(), node.GetName())
+		var safeguards []string
+		for _, v := range violations {
+			if v.ObjectKey() == key && Fixable(v.Constraint) && !slices.Contains(safeguards, v.Constraint) {
+				safeguards = append(safeguards, v.Constraint)
+			}
+		}
+		slices.Sort(safeguards)
+
+		for _, sg := range safeguards {
+			fixed, err := fixers[sg](node)
+			if err != nil {
+				return nil, nil, fmt.Errorf("fixing %s in Deployment %s: %w", sg, node.GetName(), err)
+			}
+			for _, change := range fixed {
+				changes = append(changes, fmt.Sprintf("Deployment %s: %s", node.GetName(), change))
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		return content, nil, nil
+	}
+
+	var out bytes.Buffer
+	rw.Writer = &out
+	if err = rw.Write(nodes); err != nil {
+		return nil, nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	return out.Bytes(), changes, nil
+}
+
+// fixResourceLimits adds default cpu and memory requests and limits to every container missing them
+func fixResourceLimits(deployment *yaml.RNode) ([]string, error) {
+	defaults := []struct {
+		path  []string
+		field string
+		value string
+	}{
+		{[]string{"resources", "requests"}, "cpu", defaultCPURequest},
+		{[]string{"resources", "requests"}, "memory", defaultMemoryRequest},
+		{[]string{"resources", "limits"}, "cpu", defaultCPULimit},
+		{[]string{"resources", "limits"}, "memory", defaultMemoryLimit},
+	}
+
+	var changes []string
+	err := visitContainers(deployment, func(container *yaml.RNode, name string) error {
+		for _, d := range defaults {
+			parent, err := container.Pipe(yaml.LookupCreate(yaml.MappingNode, d.path...))
+			if err != nil {
+				return err
+			}
+			if parent.Field(d.field) != nil {
+				continue
+			}
+			if err = parent.PipeE(yaml.SetField(d.field, yaml.NewStringRNode(d.value))); err != nil {
+				return err
+			}
+			changes = append(changes, fmt.Sprintf("set %s.%s of container %s to %s", d.path[1], d.field, name, d.value))
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// fixProbes adds TCP liveness and readiness probes on the first declared port to every container missing them
+func fixProbes(deployment *yaml.RNode) ([]string, error) {
+	var changes []string
+	err := visitContainers(deployment, func(container *yaml.RNode, name string) error {
+		port, err := container.Pipe(yaml.Lookup("ports", "0", "containerPort"))
+		if err != nil {
+			return err
+		}
+		if port == nil {
+			log.Warnf("cannot add probes to container %s of Deployment %s: no container port declared", name, deployment.GetName())
+			return nil
+		}
+
+		for _, probeName := range []string{"livenessProbe", "readinessProbe"} {
+			probe, err := container.Pipe(yaml.LookupCreate(yaml.MappingNode, probeName))
+			if err != nil {
+				return err
+			}
+			if slices.ContainsFunc(probeHandlers, func(handler string) bool { return probe.Field(handler) != nil }) {
+				continue
+			}
+
+			tcpSocket, err := probe.Pipe(yaml.LookupCreate(yaml.MappingNode, "tcpSocket"))
+			if err != nil {
+				return err
+			}
+			if err = tcpSocket.PipeE(yaml.SetField("port", port.Copy())); err != nil {
+				return err
+			}
+			changes = append(changes, fmt.Sprintf("added tcp %s on port %s to container %s", probeName, port.YNode().Value, name))
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// fixAntiAffinity prefers spreading the Deployment's pods across nodes using its app label, or its selector without one
+func fixAntiAffinity(deployment *yaml.RNode) ([]string, error) {
+	podSpec, err := deployment.Pipe(yaml.Lookup("spec", "template", "spec"))
+	if err != nil || podSpec == nil {
+		return nil, err
+	}
+	if existing, err := podSpec.Pipe(yaml.Lookup("affinity", "podAntiAffinity")); err != nil || existing != nil {
+		return nil, err
+	}
+
+	var labels map[string]string
+	if labelsNode, err := deployment.Pipe(yaml.Lookup("spec", "template", "metadata", "labels")); err == nil && labelsNode != nil {
+		if value, err := labelsNode.Pipe(yaml.Lookup(appLabel)); err == nil && value != nil {
+			labels = map[string]string{appLabel: value.YNode().Value}
+		}
+	}
+	if labels == nil {
+		selector, err := deployment.Pipe(yaml.Lookup("spec", "selector", "matchLabels"))
+		if err != nil {
+			return nil, err
+		}
+		if selector == nil {
+			log.Warnf("cannot add pod anti-affinity to Deployment %s: no labels to select its pods", deployment.GetName())
+			return nil, nil
+		}
+		labels = selector.GetDataMap()
+	}
+
+	term, err := yaml.Parse(fmt.Sprintf("weight: %s\npodAffinityTerm:\n  topologyKey: %s\n", antiAffinityWeight, antiAffinityTopologyKey))
+	if err != nil {
+		return nil, err
+	}
+	if err = term.PipeE(yaml.LookupCreate(yaml.MappingNode, "podAffinityTerm", "labelSelector"), yaml.SetField("matchLabels", yaml.NewMapRNode(&labels))); err != nil {
+		return nil, err
+	}
+
+	preferred := yaml.NewListRNode()
+	preferred.YNode().Content = append(preferred.YNode().Content, term.YNode())
+	if err = podSpec.PipeE(yaml.LookupCreate(yaml.MappingNode, "affinity", "podAntiAffinity"), yaml.SetField("preferredDuringSchedulingIgnoredDuringExecution", preferred)); err != nil {
+		return nil, err
+	}
+
+	return []string{fmt.Sprintf("added preferred pod anti-affinity on %s", antiAffinityTopologyKey)}, nil
+}
+
+// visitContainers calls fn for every container of a Deployment's pod template, skipping containers without a name
+func visitContainers(deployment *yaml.RNode, fn func(container *yaml.RNode, name string) error) error {
+	containers, err := deployment.Pipe(yaml.Lookup("spec", "template", "spec", "containers"))
+	if err != nil || containers == nil {
+		return err
+	}
+	return containers.VisitElements(func(container *yaml.RNode) error {
+		name, err := container.GetString("name")
+		if err != nil || name == "" {
+			log.Warnf("skipping container without a name in Deployment %s", deployment.GetName())
+			return nil
+		}
+		return fn(container, name)
+	})
+}
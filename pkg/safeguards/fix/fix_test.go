@@ -0,0 +1,197 @@
+package fix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const testManifest = `# the web frontend
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 3 # scaled for peak traffic
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: web:1.0
+          ports:
+            - containerPort: 8080
+          resources:
+            limits:
+              memory: 1Gi
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  ports:
+    - port: 80
+`
+
+func testViolations(safeguards ...string) []types.Violation {
+	var violations []types.Violation
+	for _, sg := range safeguards {
+		violations = append(violations, types.Violation{Constraint: sg, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"})
+	}
+	return violations
+}
+
+func TestManifest(t *testing.T) {
+	fixed, changes, err := Manifest([]byte(testManifest), testViolations(types.Constraint_CRL, types.Constraint_CEP, types.Constraint_PEA))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"Deployment web: added tcp livenessProbe on port 8080 to container web",
+		"Deployment web: set requests.cpu of container web to 100m",
+		"Deployment web: set requests.memory of container web to 128Mi",
+		"Deployment web: set limits.cpu of container web to 500m",
+		"Deployment web: added preferred pod anti-affinity on kubernetes.io/hostname",
+	}, changes)
+
+	expected := `# the web frontend
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 3 # scaled for peak traffic
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: web:1.0
+          ports:
+            - containerPort: 8080
+          resources:
+            limits:
+              memory: 1Gi
+              cpu: 500m
+            requests:
+              cpu: 100m
+              memory: 128Mi
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: 8080
+          livenessProbe:
+            tcpSocket:
+              port: 8080
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+            - weight: 100
+              podAffinityTerm:
+                topologyKey: kubernetes.io/hostname
+                labelSelector:
+                  matchLabels:
+                    app: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  ports:
+    - port: 80
+`
+	assert.Equal(t, expected, string(fixed))
+}
+
+func TestManifestOnlyFixesViolations(t *testing.T) {
+	fixed, changes, err := Manifest([]byte(testManifest), testViolations(types.Constraint_CEP))
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.NotContains(t, string(fixed), "requests:")
+	assert.NotContains(t, string(fixed), "affinity:")
+}
+
+func TestManifestNoFixableViolations(t *testing.T) {
+	violations := append(testViolations(types.Constraint_CAI), types.Violation{Constraint: types.Constraint_CRL, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "other"})
+	fixed, changes, err := Manifest([]byte(testManifest), violations)
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, testManifest, string(fixed))
+}
+
+func TestManifestInvalid(t *testing.T) {
+	_, _, err := Manifest([]byte("kind: [Deployment"), testViolations(types.Constraint_CRL))
+	assert.NotNil(t, err)
+}
+
+func TestManifestContainerWithoutName(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+        - image: sidecar:1.0
+        - name: web
+          image: web:1.0
+          ports:
+            - containerPort: 8080
+`
+	fixed, changes, err := Manifest([]byte(manifest), testViolations(types.Constraint_CEP))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"Deployment web: added tcp livenessProbe on port 8080 to container web",
+		"Deployment web: added tcp readinessProbe on port 8080 to container web",
+	}, changes)
+	assert.Contains(t, string(fixed), "- image: sidecar:1.0\n        - name: web")
+}
+
+func TestManifestGRPCProbe(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:1.0
+          ports:
+            - containerPort: 8080
+          readinessProbe:
+            grpc:
+              port: 8080
+`
+	fixed, changes, err := Manifest([]byte(manifest), testViolations(types.Constraint_CEP))
+	assert.Nil(t, err)
+	// the grpc readiness probe is kept as is, adding a second handler to it would be rejected
+	assert.Equal(t, []string{"Deployment web: added tcp livenessProbe on port 8080 to container web"}, changes)
+	assert.Contains(t, string(fixed), "readinessProbe:\n            grpc:\n              port: 8080\n")
+	assert.Equal(t, 1, strings.Count(string(fixed), "tcpSocket:"))
+}
@@ -0,0 +1,208 @@
+//go:build ignore
+
+// Command generate_schemas converts the OpenAPI definitions of a Kubernetes release into the standalone, strict JSON
+// schemas of the kinds draft embeds for offline schema validation, laid out like the kubernetes-json-schema repository
+// kubeconform reads from.
+//
+// The OpenAPI definitions are the api/openapi-spec/swagger.json of the release in the kubernetes repository, downloaded
+// from swaggerURL unless a local copy of the file is given.
+//
+// Usage: go run generate_schemas.go <kubernetes version> [swagger.json of the release]
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kinds are the embedded kinds, the ones draft generates and the workloads around them
+var kinds = []string{
+	"io.k8s.api.apps.v1.Deployment",
+	"io.k8s.api.apps.v1.StatefulSet",
+	"io.k8s.api.apps.v1.DaemonSet",
+	"io.k8s.api.autoscaling.v2.HorizontalPodAutoscaler",
+	"io.k8s.api.batch.v1.CronJob",
+	"io.k8s.api.batch.v1.Job",
+	"io.k8s.api.core.v1.ConfigMap",
+	"io.k8s.api.core.v1.Namespace",
+	"io.k8s.api.core.v1.Pod",
+	"io.k8s.api.core.v1.Secret",
+	"io.k8s.api.core.v1.Service",
+	"io.k8s.api.core.v1.ServiceAccount",
+	"io.k8s.api.networking.v1.Ingress",
+	"io.k8s.api.policy.v1.PodDisruptionBudget",
+}
+
+const (
+	swaggerURL      = "https://raw.githubusercontent.com/kubernetes/kubernetes/v%s/api/openapi-spec/swagger.json"
+	refPrefix       = "#/definitions/"
+	quantityRef     = "io.k8s.apimachinery.pkg.api.resource.Quantity"
+	intOrStringType = "int-or-string"
+)
+
+func main() {
+	if len(os.Args) != 2 && len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: go run generate_schemas.go <kubernetes version> [swagger.json]")
+		os.Exit(1)
+	}
+	swaggerPath := ""
+	if len(os.Args) == 3 {
+		swaggerPath = os.Args[2]
+	}
+	if err := generate(strings.TrimPrefix(os.Args[1], "v"), swaggerPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate(version, swaggerPath string) error {
+	content, err := readSwagger(version, swaggerPath)
+	if err != nil {
+		return err
+	}
+	var swagger struct {
+		Definitions map[string]map[string]any `json:"definitions"`
+	}
+	if err = json.Unmarshal(content, &swagger); err != nil {
+		return fmt.Errorf("parsing the swagger.json of %s: %w", version, err)
+	}
+
+	dir := filepath.Join("schemas", fmt.Sprintf("v%s-standalone-strict", version))
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range kinds {
+		definition, ok := swagger.Definitions[name]
+		if !ok {
+			return fmt.Errorf("definition %s not found", name)
+		}
+		gvks, _ := definition["x-kubernetes-group-version-kind"].([]any)
+		if len(gvks) != 1 {
+			return fmt.Errorf("definition %s has no single group version kind", name)
+		}
+		gvk := gvks[0].(map[string]any)
+		group, apiVersion, kind := gvk["group"].(string), gvk["version"].(string), gvk["kind"].(string)
+
+		fileName := strings.ToLower(kind)
+		if group != "" {
+			apiVersion = group + "/" + apiVersion
+			fileName += "-" + strings.ToLower(strings.Split(group, ".")[0])
+		}
+		fileName += "-" + strings.ToLower(gvk["version"].(string)) + ".json"
+
+		schema, err := expand(definition, swagger.Definitions, nil)
+		if err != nil {
+			return fmt.Errorf("expanding %s: %w", name, err)
+		}
+		properties := schema["properties"].(map[string]any)
+		properties["apiVersion"] = map[string]any{"type": []any{"string", "null"}, "enum": []any{apiVersion}}
+		properties["kind"] = map[string]any{"type": []any{"string", "null"}, "enum": []any{kind}}
+
+		out, err := json.Marshal(schema)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(dir, fileName), append(out, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSwagger reads the swagger.json of a Kubernetes release from swaggerPath, or downloads it when swaggerPath is empty
+func readSwagger(version, swaggerPath string) ([]byte, error) {
+	if swaggerPath != "" {
+		return os.ReadFile(swaggerPath)
+	}
+
+	url := fmt.Sprintf(swaggerURL, version)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// expand inlines the references of a schema, rejects properties it does not declare, and lets optional properties be
+// null, like the strict standalone schemas of kubernetes-json-schema. Descriptions are dropped to keep the schemas small.
+func expand(schema map[string]any, definitions map[string]map[string]any, seen []string) (map[string]any, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, refPrefix)
+		for _, s := range seen {
+			if s == name {
+				return nil, fmt.Errorf("recursive definition %s", name)
+			}
+		}
+		if name == quantityRef {
+			return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}}, nil
+		}
+		definition, ok := definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("definition %s not found", name)
+		}
+		return expand(definition, definitions, append(seen, name))
+	}
+	if schema["format"] == intOrStringType {
+		return map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}}, nil
+	}
+
+	expanded := make(map[string]any)
+	for key, value := range schema {
+		if key == "description" || strings.HasPrefix(key, "x-kubernetes-") {
+			continue
+		}
+		switch key {
+		case "properties":
+			required := make(map[string]bool)
+			if list, ok := schema["required"].([]any); ok {
+				for _, r := range list {
+					required[r.(string)] = true
+				}
+			}
+			properties := make(map[string]any)
+			for name, property := range value.(map[string]any) {
+				p, err := expand(property.(map[string]any), definitions, seen)
+				if err != nil {
+					return nil, err
+				}
+				if t, ok := p["type"].(string); ok && !required[name] {
+					p["type"] = []any{t, "null"}
+				}
+				properties[name] = p
+			}
+			expanded[key] = properties
+		case "items", "additionalProperties":
+			nested, ok := value.(map[string]any)
+			if !ok {
+				expanded[key] = value
+				continue
+			}
+			p, err := expand(nested, definitions, seen)
+			if err != nil {
+				return nil, err
+			}
+			expanded[key] = p
+		default:
+			expanded[key] = value
+		}
+	}
+	if _, ok := expanded["properties"]; ok {
+		if _, ok := expanded["additionalProperties"]; !ok {
+			expanded["additionalProperties"] = false
+		}
+	}
+	return expanded, nil
+}
@@ -1,13 +1,17 @@
 package safeguards
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"helm.sh/helm/v3/pkg/chartutil"
 
@@ -18,6 +22,7 @@ import (
 	"github.com/open-policy-agent/gatekeeper/v3/pkg/target"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/Azure/draft/pkg/safeguards/preprocessing"
@@ -26,27 +31,42 @@ import (
 
 // Given a path, will determine if it's Kustomize, Helm, a directory of manifests, or a single manifest
 func GetManifestFiles(manifestsPath string, opt chartutil.ReleaseOptions) ([]types.ManifestFile, error) {
+	return GetManifestFilesWithOptions(manifestsPath, opt, nil, nil)
+}
+
+// GetManifestFilesWithOptions retrieves manifest files like GetManifestFiles, rendering Helm charts with the given values
+// files and --set overrides and kustomize roots with the given overlays. Overrides are rejected for paths that are not
+// Helm charts and overlays for paths that are not kustomize roots.
+func GetManifestFilesWithOptions(manifestsPath string, opt chartutil.ReleaseOptions, helmOpts *preprocessing.HelmOptions, kustomizeOpts *preprocessing.KustomizeOptions) ([]types.ManifestFile, error) {
 	isDir, err := IsDirectory(manifestsPath)
 	if err != nil {
 		return nil, fmt.Errorf("not a valid file or directory: %w", err)
 	}
 
+	hasValues := helmOpts != nil && (len(helmOpts.ValueFiles) > 0 || len(helmOpts.Values) > 0)
+	if hasValues && !isHelm(isDir, manifestsPath) {
+		return nil, fmt.Errorf("values files and --set overrides can only be used with helm charts")
+	}
+	if kustomizeOpts != nil && len(kustomizeOpts.Overlays) > 0 && !isKustomize(isDir, manifestsPath) {
+		return nil, fmt.Errorf("overlays can only be used with kustomize projects")
+	}
+
 	var manifestFiles []types.ManifestFile
 	if isDir {
 		// check if Helm or Kustomize dir
 		if isHelm(true, manifestsPath) {
-			return preprocessing.RenderHelmChart(false, manifestsPath, opt)
+			return preprocessing.RenderHelmChartWithOptions(false, manifestsPath, opt, helmOpts)
 		} else if isKustomize(true, manifestsPath) {
-			return preprocessing.RenderKustomizeManifest(manifestsPath)
+			return preprocessing.RenderKustomizeManifestWithOptions(manifestsPath, kustomizeOpts)
 		} else {
 			manifestFiles, err = GetManifestFilesFromDir(manifestsPath)
 			return manifestFiles, err
 		}
 	} else if IsYAML(manifestsPath) { // path points to a file
 		if isHelm(false, manifestsPath) {
-			return preprocessing.RenderHelmChart(true, manifestsPath, opt)
+			return preprocessing.RenderHelmChartWithOptions(true, manifestsPath, opt, helmOpts)
 		} else if isKustomize(false, manifestsPath) {
-			return preprocessing.RenderKustomizeManifest(manifestsPath)
+			return preprocessing.RenderKustomizeManifestWithOptions(manifestsPath, kustomizeOpts)
 		} else {
 			byteContent, err := os.ReadFile(manifestsPath)
 			if err != nil {
@@ -55,6 +75,7 @@ func GetManifestFiles(manifestsPath string, opt chartutil.ReleaseOptions) ([]typ
 			manifestFiles = append(manifestFiles, types.ManifestFile{
 				Name:            path.Base(manifestsPath),
 				ManifestContent: byteContent,
+				Source:          manifestsPath,
 			})
 		}
 		return manifestFiles, nil
@@ -87,6 +108,7 @@ func GetManifestFilesFromDir(p string) ([]types.ManifestFile, error) {
 			}
 			manifest.Name = info.Name()
 			manifest.ManifestContent = byteContent
+			manifest.Source = walkPath
 			manifestFiles = append(manifestFiles, manifest)
 		} else if !IsYAML(p) {
 			log.Debugf("%s is not a manifest file, skipping...", info.Name())
@@ -106,6 +128,25 @@ func GetManifestFilesFromDir(p string) ([]types.ManifestFile, error) {
 	return manifestFiles, nil
 }
 
+// GetManifestFilesFromReader reads a stream of manifests, like the output of any renderer piped to draft, into a single
+// manifest file with the given name
+func GetManifestFilesFromReader(r io.Reader, name string) ([]types.ManifestFile, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifests from %s: %w", name, err)
+	}
+
+	objects, err := types.FileCrawler{}.ReadManifests(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no manifests found in %s", name)
+	}
+
+	return []types.ManifestFile{{Name: name, ManifestContent: content}}, nil
+}
+
 // retrieves the constraint client that does all rego code related operations
 func getConstraintClient() (*constraintclient.Client, error) {
 	driver, err := rego.New()
@@ -125,22 +166,44 @@ func getConstraintClient() (*constraintclient.Client, error) {
 	return c, nil
 }
 
-// sorts the list of supported safeguards versions and returns the last item in the list
-func getLatestSafeguardsVersion() string {
-	semver.Sort(types.SupportedVersions)
-	return types.SupportedVersions[len(types.SupportedVersions)-1]
+// SupportedVersions lists the versions of the embedded safeguards library, oldest first
+func SupportedVersions() ([]string, error) {
+	entries, err := fs.ReadDir(embedFS, "lib")
+	if err != nil {
+		return nil, fmt.Errorf("reading safeguards versions from embedded fs: %w", err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && semver.IsValid(entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	semver.Sort(versions)
+	return versions, nil
 }
 
-func updateSafeguardPaths(safeguardList *[]types.Safeguard) {
-	for _, sg := range *safeguardList {
-		sg.TemplatePath = fmt.Sprintf("%s/%s/%s", types.SelectedVersion, sg.Name, types.TemplateFileName)
-		sg.ConstraintPath = fmt.Sprintf("%s/%s/%s", types.SelectedVersion, sg.Name, types.ConstraintFileName)
+// returns the newest version of the embedded safeguards library
+func getLatestSafeguardsVersion() (string, error) {
+	versions, err := SupportedVersions()
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no safeguards versions found in embedded fs")
 	}
+	return versions[len(versions)-1], nil
 }
 
-// adds Safeguard_CRIP to full list of Safeguards
+// cripAdded reports whether AddSafeguardCRIP was called
+var cripAdded atomic.Bool
+
+// AddSafeguardCRIP adds container-restricted-image-pulls to the safeguards evaluated by the package-level
+// GetManifestResults. Validators are not affected.
+//
+// Deprecated: list types.Constraint_CRIP in the ValidatorOptions.Safeguards of a Validator instead.
 func AddSafeguardCRIP() {
-	fc.Safeguards = append(fc.Safeguards, types.Safeguard_CRIP)
+	cripAdded.Store(true)
 }
 
 // loads constraint templates, constraints into constraint client
@@ -198,6 +261,16 @@ func loadManifestObjects(ctx context.Context, c *constraintclient.Client, object
 	return nil
 }
 
+// removeManifestObjects removes previously loaded manifest objects from the constraint client, logging failures since
+// the objects would only affect later reviews by referential safeguards
+func removeManifestObjects(ctx context.Context, c *constraintclient.Client, objects []*unstructured.Unstructured) {
+	for _, o := range objects {
+		if _, err := c.RemoveData(ctx, o); err != nil {
+			log.Debugf("could not remove data: %s", err.Error())
+		}
+	}
+}
+
 // IsDirectory determines if a file represented by path is a directory or not
 func IsDirectory(path string) (bool, error) {
 	fileInfo, err := os.Stat(path)
@@ -213,8 +286,9 @@ func IsYAML(path string) bool {
 	return filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml"
 }
 
-// getObjectViolations executes validation on manifests based on loaded constraint templates and returns a map of manifest name to list of objectViolations
-func getObjectViolations(ctx context.Context, c *constraintclient.Client, objects []*unstructured.Unstructured) (map[string][]string, error) {
+// getObjectViolations executes validation on manifests based on loaded constraint templates and returns every violation found,
+// in the order the objects were reviewed
+func getObjectViolations(ctx context.Context, c *constraintclient.Client, objects []*unstructured.Unstructured) ([]types.Violation, error) {
 	if c == nil {
 		return nil, fmt.Errorf("constraint client is nil")
 	}
@@ -223,30 +297,80 @@ func getObjectViolations(ctx context.Context, c *constraintclient.Client, object
 	// On error, the responses return value will still be populated so that
 	// partial results can be analyzed.
 
-	var results = make(map[string][]string) // map of object name to slice of objectViolations
+	var violations []types.Violation
 
 	for _, o := range objects {
-		objectViolations := []string{}
 		log.Debugf("Reviewing %s...", o.GetName())
 		res, err := c.Review(ctx, o)
 		if err != nil {
-			return results, fmt.Errorf("could not review objects: %w", err)
+			return violations, fmt.Errorf("could not review objects: %w", err)
 		}
 
 		for _, v := range res.ByTarget {
 			for _, result := range v.Results {
-				if result.Msg != "" {
-					objectViolations = append(objectViolations, result.Msg)
+				if result.Msg == "" {
+					continue
+				}
+
+				violation := types.Violation{
+					APIVersion: o.GetAPIVersion(),
+					Kind:       o.GetKind(),
+					Namespace:  o.GetNamespace(),
+					Name:       o.GetName(),
+					Message:    result.Msg,
 				}
+				if result.Constraint != nil {
+					violation.Constraint = result.Constraint.GetName()
+					violation.ConstraintName = result.Constraint.GetName()
+				}
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// objectPosition locates an object within a multi-document manifest
+type objectPosition struct {
+	document int // the index of the object's document, counting every document in the manifest
+	line     int // the line the object starts on
+}
+
+// getObjectPositions maps the ObjectKey of every object in a multi-document manifest to its document and starting line
+func getObjectPositions(content []byte) map[string]objectPosition {
+	positions := make(map[string]objectPosition)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for document := 0; ; document++ {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Debugf("resolving object positions: %s", err.Error())
 			}
+			return positions
+		}
+		if len(doc.Content) == 0 {
+			continue
 		}
 
-		if len(objectViolations) > 0 {
-			results[o.GetName()] = objectViolations
+		var object struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err := doc.Decode(&object); err != nil {
+			continue
 		}
-	}
 
-	return results, nil
+		key := types.ObjectKey(object.APIVersion, object.Kind, object.Metadata.Namespace, object.Metadata.Name)
+		if _, ok := positions[key]; !ok {
+			positions[key] = objectPosition{document: document, line: doc.Content[0].Line}
+		}
+	}
 }
 
 // Checks whether a given path is a helm directory or a path to a Helm Chart (contains/is Chart.yaml)
@@ -282,7 +406,8 @@ func isKustomize(isDir bool, p string) bool {
 		} else if _, err = os.Stat(filepath.Join(p, "kustomization.yml")); err == nil {
 			return true
 		} else {
-			return false
+			// a kustomize root like draft's base plus overlays layout has no kustomization of its own
+			return preprocessing.HasKustomizeOverlays(p)
 		}
 	} else {
 		return strings.Contains(p, "kustomization.yaml")
@@ -1,11 +1,13 @@
 package safeguards
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/Azure/draft/pkg/safeguards/preprocessing"
 	"github.com/Azure/draft/pkg/safeguards/types"
 	constraintclient "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/stretchr/testify/assert"
@@ -148,3 +150,107 @@ func TestIsHelm(t *testing.T) {
 	ishelm = isHelm(false, "invalid/path")
 	assert.False(t, ishelm)
 }
+
+func TestGetObjectPositions(t *testing.T) {
+	content := []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app
+---
+# the deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+  namespace: prod
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app
+  namespace: prod
+`)
+
+	positions := getObjectPositions(content)
+	assert.Equal(t, objectPosition{document: 0, line: 1}, positions[types.ObjectKey("v1", "Service", "", "my-app")])
+	assert.Equal(t, objectPosition{document: 1, line: 7}, positions[types.ObjectKey("apps/v1", "Deployment", "prod", "my-app")])
+	assert.Equal(t, objectPosition{document: 3, line: 14}, positions[types.ObjectKey("v1", "ConfigMap", "prod", "my-app")])
+	assert.Equal(t, objectPosition{}, positions[types.ObjectKey("apps/v1", "Deployment", "", "missing")])
+}
+
+// TestGetManifestResultsObjectIdentity tests that a Deployment and Service sharing a name, as draft generates them, are
+// reported separately along with the full path of their manifest and their document index
+func TestGetManifestResultsObjectIdentity(t *testing.T) {
+	ctx := context.Background()
+	manifestPath := "tests/object-identity/same-name-manifest.yaml"
+	var opt chartutil.ReleaseOptions
+
+	manifestFiles, err := GetManifestFiles(manifestPath, opt)
+	assert.Nil(t, err)
+	results, err := GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, manifestPath, results[0].Source)
+
+	deploymentKey := types.ObjectKey("apps/v1", "Deployment", "prod", "myapp")
+	serviceKey := types.ObjectKey("v1", "Service", "prod", "myapp")
+	assert.Contains(t, results[0].ObjectViolations, deploymentKey)
+	assert.Contains(t, results[0].ObjectViolations, serviceKey)
+
+	for _, v := range results[0].Violations {
+		assert.Equal(t, manifestPath, v.File)
+		assert.NotEmpty(t, v.ConstraintName)
+		switch v.ObjectKey() {
+		case deploymentKey:
+			assert.Equal(t, 0, v.Document)
+		case serviceKey:
+			assert.Equal(t, types.Constraint_USS, v.Constraint)
+			assert.Equal(t, 1, v.Document)
+			assert.Equal(t, 20, v.Line)
+		}
+	}
+}
+
+func TestGetManifestFilesWithOptions(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	helmOpts := &preprocessing.HelmOptions{Values: []string{"replicaCount=2"}}
+
+	_, err := GetManifestFilesWithOptions(chartPath, opt, helmOpts, nil)
+	assert.Nil(t, err)
+
+	// overrides are only supported for helm charts
+	_, err = GetManifestFilesWithOptions(kustomizationPath, opt, helmOpts, nil)
+	assert.NotNil(t, err)
+	_, err = GetManifestFilesWithOptions(kustomizationPath, opt, &preprocessing.HelmOptions{}, nil)
+	assert.Nil(t, err)
+}
+
+func TestGetManifestFilesKustomizeRoot(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	kustomizeOpts := &preprocessing.KustomizeOptions{Overlays: []string{"production"}}
+
+	assert.True(t, isKustomize(true, "tests/kustomize"))
+	manifestFiles, err := GetManifestFilesWithOptions("tests/kustomize", opt, nil, kustomizeOpts)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, manifestFiles)
+
+	// overlays are only supported for kustomize projects
+	_, err = GetManifestFilesWithOptions(chartPath, opt, nil, kustomizeOpts)
+	assert.NotNil(t, err)
+}
+
+func TestGetManifestFilesFromReader(t *testing.T) {
+	content, err := os.ReadFile("tests/all/success/all-success-manifest-1.yaml")
+	assert.Nil(t, err)
+
+	manifestFiles, err := GetManifestFilesFromReader(bytes.NewReader(content), "stdin")
+	assert.Nil(t, err)
+	assert.Equal(t, []types.ManifestFile{{Name: "stdin", ManifestContent: content}}, manifestFiles)
+
+	_, err = GetManifestFilesFromReader(bytes.NewReader([]byte("# nothing rendered\n")), "stdin")
+	assert.NotNil(t, err)
+
+	_, err = GetManifestFilesFromReader(bytes.NewReader([]byte("kind: [Deployment")), "stdin")
+	assert.NotNil(t, err)
+}
@@ -7,103 +7,117 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/Azure/draft/pkg/safeguards/types"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
 )
 
 //go:embed lib
 var embedFS embed.FS
 
-var fc types.FileCrawler
-
-// primes the scheme to be able to interpret beta templates
-func init() {
-
-	types.SelectedVersion = getLatestSafeguardsVersion()
-	updateSafeguardPaths(&types.Safeguards)
-
-	fc = types.FileCrawler{
-		Safeguards:   types.Safeguards,
-		ConstraintFS: embedFS,
-	}
-}
-
 type ManifestResult struct {
 	Name             string              // the name of the manifest
 	ObjectViolations map[string][]string // a map of string object names to slice of string objectViolations
 	ViolationsCount  int                 // a count of how many violations are associated with this manifest
 }
 
-// GetManifestResults takes in a list of manifest files and returns a slice of ManifestViolation structs
+// GetManifestResults takes in a list of manifest files and returns a slice of ManifestViolation structs. It evaluates the
+// built-in safeguards of the latest version, with container-restricted-image-pulls once AddSafeguardCRIP was called. Use
+// a Validator to choose the version, safeguards, policies and configuration.
 func GetManifestResults(ctx context.Context, manifestFiles []types.ManifestFile) ([]types.ManifestResult, error) {
 	if len(manifestFiles) == 0 {
 		return nil, fmt.Errorf("path cannot be empty")
 	}
 
-	manifestResults := make([]types.ManifestResult, 0)
-
-	// constraint client instantiation
-	c, err := getConstraintClient()
-	if err != nil {
-		return manifestResults, err
-	}
-
-	// retrieval of templates, constraints, and deployment
-	constraintTemplates, err := fc.ReadConstraintTemplates()
-	if err != nil {
-		return manifestResults, err
+	var opts ValidatorOptions
+	if cripAdded.Load() {
+		for _, sg := range types.Safeguards {
+			opts.Safeguards = append(opts.Safeguards, sg.Name)
+		}
+		opts.Safeguards = append(opts.Safeguards, types.Constraint_CRIP)
 	}
-	constraints, err := fc.ReadConstraints()
+	v, err := NewValidator(ctx, opts)
 	if err != nil {
-		return manifestResults, err
+		return make([]types.ManifestResult, 0), err
 	}
+	return v.GetManifestResults(ctx, manifestFiles)
+}
 
-	// loading of templates, constraints into constraint client
-	err = loadConstraintTemplates(ctx, c, constraintTemplates)
-	if err != nil {
-		return manifestResults, err
-	}
-	err = loadConstraints(ctx, c, constraints)
-	if err != nil {
-		return manifestResults, err
+// GetManifestResults validates the manifest files against the validator's safeguards. The manifest objects are removed
+// from the client afterwards, so every call only sees the objects of its own manifest files. Concurrent calls are
+// reviewed one at a time.
+func (v *Validator) GetManifestResults(ctx context.Context, manifestFiles []types.ManifestFile) ([]types.ManifestResult, error) {
+	if len(manifestFiles) == 0 {
+		return nil, fmt.Errorf("path cannot be empty")
 	}
 
-	// organized map of manifest object by file name
-	manifestMap := make(map[string][]*unstructured.Unstructured, 0)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	manifestResults := make([]types.ManifestResult, 0)
+	c := v.client
+
+	// manifest objects by the index of their manifest file, names are not unique across rendered charts and kustomizations
+	manifestObjectsByFile := make([][]*unstructured.Unstructured, len(manifestFiles))
 	// aggregate of every manifest object into one list
 	allManifestObjects := []*unstructured.Unstructured{}
-	for _, m := range manifestFiles {
-		manifestObjects, err := fc.ReadManifests(m.ManifestContent) // read all the objects stored in a single file
+	for i, m := range manifestFiles {
+		manifestObjects, err := types.FileCrawler{}.ReadManifests(m.ManifestContent) // read all the objects stored in a single file
 		if err != nil {
 			log.Errorf("reading objects %s", err.Error())
 			return manifestResults, err
 		}
 
 		allManifestObjects = append(allManifestObjects, manifestObjects...)
-		manifestMap[m.Name] = manifestObjects
+		manifestObjectsByFile[i] = manifestObjects
 	}
 
 	if len(allManifestObjects) > 0 {
+		defer removeManifestObjects(ctx, c, allManifestObjects)
 		err := loadManifestObjects(ctx, c, allManifestObjects)
 		if err != nil {
 			return manifestResults, err
 		}
 	}
 
-	for _, m := range manifestFiles {
-		var objectViolations map[string][]string
-
+	for fileIndex, m := range manifestFiles {
 		// validation of deployment manifest with constraints, templates loaded
-		objectViolations, err = getObjectViolations(ctx, c, manifestMap[m.Name])
+		violations, err := getObjectViolations(ctx, c, manifestObjectsByFile[fileIndex])
 		if err != nil {
 			log.Errorf("validating objects: %s", err.Error())
 			return manifestResults, err
 		}
 
+		file := m.Source
+		if file == "" {
+			file = m.Name
+		}
+		objectPositions := getObjectPositions(m.ManifestContent)
+		for i, violation := range violations {
+			if sgName, ok := v.safeguardNames[violation.Constraint]; ok {
+				violations[i].Constraint = sgName
+			}
+			violations[i].Severity = getSeverity(v.config, violations[i].Constraint)
+			violations[i].File = file
+			violations[i].Document = objectPositions[violation.ObjectKey()].document
+			violations[i].Line = objectPositions[violation.ObjectKey()].line
+		}
+
+		// violations the objects suppress through annotations are listed separately
+		violations, suppressed := suppressViolations(manifestObjectsByFile[fileIndex], violations)
+
+		objectViolations := make(map[string][]string)
+		for _, violation := range violations {
+			objectViolations[violation.ObjectKey()] = append(objectViolations[violation.ObjectKey()], violation.Message)
+		}
+
 		manifestResults = append(manifestResults, types.ManifestResult{
 			Name:             m.Name,
+			Source:           m.Source,
 			ObjectViolations: objectViolations,
-			ViolationsCount:  len(objectViolations),
+			ViolationsCount:  len(violations),
+			Violations:       violations,
+			Suppressed:       suppressed,
 		})
 	}
 
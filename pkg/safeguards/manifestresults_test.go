@@ -15,11 +15,21 @@ var ctx = context.Background()
 var testFc types.FileCrawler
 
 func init() {
-	types.SelectedVersion = getLatestSafeguardsVersion()
-	updateSafeguardPaths(&types.SafeguardsTesting)
-
+	// the test safeguards are read from the latest version of the embedded library
+	version, err := getLatestSafeguardsVersion()
+	if err != nil {
+		panic(err)
+	}
+	var names []string
+	for _, sg := range types.SafeguardsTesting {
+		names = append(names, sg.Name)
+	}
+	safeguards, err := builtinSafeguards(version, names)
+	if err != nil {
+		panic(err)
+	}
 	testFc = types.FileCrawler{
-		Safeguards:   types.SafeguardsTesting,
+		Safeguards:   safeguards,
 		ConstraintFS: embedFS,
 	}
 }
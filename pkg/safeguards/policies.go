@@ -0,0 +1,100 @@
+package safeguards
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// readPolicyFS lists the policies at the root of a policy file system, one per directory holding a template.yaml and
+// constraint.yaml, failing when it holds no policies. Every policy is loaded as one safeguard named after its directory,
+// following the layout of the built-in safeguards library.
+func readPolicyFS(fsys fs.FS) ([]types.Safeguard, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []types.Safeguard
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		templatePath := path.Join(entry.Name(), types.TemplateFileName)
+		constraintPath := path.Join(entry.Name(), types.ConstraintFileName)
+		_, templateErr := fs.Stat(fsys, templatePath)
+		_, constraintErr := fs.Stat(fsys, constraintPath)
+		if templateErr != nil && constraintErr != nil {
+			log.Debugf("%s does not contain a policy, skipping...", entry.Name())
+			continue
+		}
+		if templateErr != nil || constraintErr != nil {
+			return nil, fmt.Errorf("policy %s must contain both %s and %s", entry.Name(), types.TemplateFileName, types.ConstraintFileName)
+		}
+
+		policies = append(policies, types.Safeguard{
+			Name:           entry.Name(),
+			TemplatePath:   templatePath,
+			ConstraintPath: constraintPath,
+		})
+	}
+
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no policies found")
+	}
+	return policies, nil
+}
+
+// readPolicies reads the constraint templates and constraints of every safeguard of the crawlers the configuration does
+// not exclude, failing on duplicate template or constraint names. It also returns a map of constraint name to the
+// safeguard it belongs to.
+func readPolicies(crawlers []types.FileCrawler, config types.SafeguardsConfig) ([]*templates.ConstraintTemplate, []*unstructured.Unstructured, map[string]string, error) {
+	var constraintTemplates []*templates.ConstraintTemplate
+	var constraints []*unstructured.Unstructured
+	safeguardNames := make(map[string]string)
+	templateNames := make(map[string]string)
+
+	for _, crawler := range crawlers {
+		crawler.Safeguards = enabledSafeguards(config, crawler.Safeguards)
+
+		crawlerTemplates, err := crawler.ReadConstraintTemplates()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		crawlerConstraints, err := crawler.ReadConstraints()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		// templates and constraints are read in safeguard order, so all three slices share an index
+		for i, sg := range crawler.Safeguards {
+			ct, con := crawlerTemplates[i], crawlerConstraints[i]
+
+			if existing, ok := templateNames[ct.GetName()]; ok {
+				return nil, nil, nil, fmt.Errorf("constraint template %s of safeguard %s is already defined by safeguard %s", ct.GetName(), sg.Name, existing)
+			}
+			templateNames[ct.GetName()] = sg.Name
+
+			if existing, ok := safeguardNames[con.GetName()]; ok {
+				return nil, nil, nil, fmt.Errorf("constraint %s of safeguard %s is already defined by safeguard %s", con.GetName(), sg.Name, existing)
+			}
+			safeguardNames[con.GetName()] = sg.Name
+
+			if err = applyConstraintParameters(config, sg.Name, con); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+		constraintTemplates = append(constraintTemplates, crawlerTemplates...)
+		constraints = append(constraints, crawlerConstraints...)
+	}
+
+	return constraintTemplates, constraints, safeguardNames, nil
+}
@@ -0,0 +1,41 @@
+package safeguards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const (
+	policyDir                  = "tests/policies"
+	duplicatePolicyDir         = "tests/policies-duplicate"
+	duplicateTemplatePolicyDir = "tests/policies-duplicate-template"
+)
+
+func TestNewValidatorPolicyDirs(t *testing.T) {
+	for _, dir := range []string{"tests/does-not-exist", "tests/all", duplicatePolicyDir} {
+		_, err := NewValidator(ctx, ValidatorOptions{PolicyDirs: []string{dir}})
+		assert.ErrorContains(t, err, dir)
+	}
+
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+
+	v, err := NewValidator(ctx, ValidatorOptions{PolicyDirs: []string{policyDir}})
+	assert.Nil(t, err)
+	results, err := v.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, violatedSafeguards(results), "required-owner-label")
+
+	// the policies only belong to the validator they were added to
+	results, err = GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.NotContains(t, violatedSafeguards(results), "required-owner-label")
+}
+
+func TestNewValidatorPolicyDirsDuplicateTemplate(t *testing.T) {
+	_, err := NewValidator(ctx, ValidatorOptions{PolicyDirs: []string{duplicateTemplatePolicyDir}})
+	assert.ErrorContains(t, err, "already defined")
+}
@@ -0,0 +1,165 @@
+package preprocessing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const (
+	fileRepositoryPrefix = "file://"
+	repoIndexFileName    = "index.yaml"
+)
+
+// repoIndex is the subset of a Helm repository index.yaml needed to find chart archives
+type repoIndex struct {
+	Entries map[string][]struct {
+		Version string   `yaml:"version"`
+		URLs    []string `yaml:"urls"`
+	} `yaml:"entries"`
+}
+
+// isVendoredDependency reports whether the dependency was loaded from the chart's charts/ directory
+func isVendoredDependency(c *chart.Chart, dep *chart.Dependency) bool {
+	for _, vendored := range c.Dependencies() {
+		if vendored.Name() == dep.Name || (dep.Alias != "" && vendored.Name() == dep.Alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyName returns the name a dependency is rendered under, its alias if it has one
+func dependencyName(dep *chart.Dependency) string {
+	if dep.Alias != "" {
+		return dep.Alias
+	}
+	return dep.Name
+}
+
+// removeDependency removes the named chart from the dependencies loaded from the chart's charts/ directory
+func removeDependency(c *chart.Chart, name string) {
+	var kept []*chart.Chart
+	for _, dep := range c.Dependencies() {
+		if dep.Name() == name {
+			log.Debugf("dependency %s vendored in charts/ is replaced by its file:// repository", name)
+			continue
+		}
+		kept = append(kept, dep)
+	}
+	c.SetDependencies(kept...)
+}
+
+// lockedVersion returns the version Chart.lock resolved the dependency to, or the version constraint of Chart.yaml
+// when the chart has no lock file
+func lockedVersion(c *chart.Chart, dep *chart.Dependency) string {
+	if c.Lock != nil {
+		for _, locked := range c.Lock.Dependencies {
+			if locked.Name == dep.Name && locked.Repository == dep.Repository {
+				return locked.Version
+			}
+		}
+	}
+	return dep.Version
+}
+
+// loadCachedDependency loads the highest version of a dependency matching version from the chart cache directory, which
+// may hold <name>-<version>.tgz archives, unpacked chart directories or a file-served repository index.yaml
+func loadCachedDependency(cacheDir string, dep *chart.Dependency, version string) (*chart.Chart, error) {
+	if cacheDir == "" {
+		return nil, fmt.Errorf("dependency is not vendored in charts/, run 'helm dependency build' or provide a chart cache")
+	}
+
+	if version == "" {
+		version = "*"
+	}
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	candidates, err := findCachedCharts(cacheDir, dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var bestVersion *semver.Version
+	var bestPath string
+	for candidatePath, candidateVersion := range candidates {
+		if !constraint.Check(candidateVersion) {
+			continue
+		}
+		if bestVersion == nil || candidateVersion.GreaterThan(bestVersion) {
+			bestVersion, bestPath = candidateVersion, candidatePath
+		}
+	}
+	if bestPath == "" {
+		return nil, fmt.Errorf("no version matching %s found in chart cache %s", version, cacheDir)
+	}
+
+	log.Debugf("resolved dependency %s %s to %s", dep.Name, version, bestPath)
+	return loader.Load(bestPath)
+}
+
+// findCachedCharts returns the paths of every cached copy of the named chart mapped to its version
+func findCachedCharts(cacheDir, name string) (map[string]*semver.Version, error) {
+	candidates := make(map[string]*semver.Version)
+
+	archives, err := filepath.Glob(filepath.Join(cacheDir, name+"-*.tgz"))
+	if err != nil {
+		return nil, fmt.Errorf("searching chart cache: %w", err)
+	}
+	for _, archive := range archives {
+		// names sharing a prefix, like nginx and nginx-ingress, fail to parse as a version and are skipped
+		rawVersion := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(archive), name+"-"), ".tgz")
+		if v, err := semver.NewVersion(rawVersion); err == nil {
+			candidates[archive] = v
+		}
+	}
+
+	chartDir := filepath.Join(cacheDir, name)
+	if metadata, err := chartutil.LoadChartfile(filepath.Join(chartDir, chartutil.ChartfileName)); err == nil {
+		if v, err := semver.NewVersion(metadata.Version); err == nil {
+			candidates[chartDir] = v
+		}
+	}
+
+	indexContent, err := os.ReadFile(filepath.Join(cacheDir, repoIndexFileName))
+	if err != nil {
+		return candidates, nil
+	}
+	var index repoIndex
+	if err := yaml.Unmarshal(indexContent, &index); err != nil {
+		return nil, fmt.Errorf("parsing %s in chart cache: %w", repoIndexFileName, err)
+	}
+	for _, entry := range index.Entries[name] {
+		v, err := semver.NewVersion(entry.Version)
+		if err != nil {
+			continue
+		}
+		for _, url := range entry.URLs {
+			// only charts served from the local file system can be used offline
+			url = strings.TrimPrefix(url, fileRepositoryPrefix)
+			if strings.Contains(url, "://") {
+				continue
+			}
+			if !filepath.IsAbs(url) {
+				url = filepath.Join(cacheDir, url)
+			}
+			if _, err := os.Stat(url); err == nil {
+				candidates[url] = v
+				break
+			}
+		}
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,164 @@
+package preprocessing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	consts "github.com/Azure/draft/pkg/safeguards/types"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// packageRemoteChart saves the remote dependency fixture as a <name>-<version>.tgz archive into dir
+func packageRemoteChart(t *testing.T, version, dir string) {
+	remoteChart, err := loader.Load(consts.RemoteDependencySource)
+	assert.Nil(t, err)
+	remoteChart.Metadata.Version = version
+	_, err = chartutil.Save(remoteChart, dir)
+	assert.Nil(t, err)
+}
+
+// renderedWith reports whether any manifest rendered from the chart contains the given string
+func renderedWith(t *testing.T, chartPath string, helmOpts *HelmOptions, substr string) bool {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := RenderHelmChartWithOptions(false, chartPath, opt, helmOpts)
+	assert.Nil(t, err)
+	for _, m := range manifestFiles {
+		if strings.Contains(string(m.ManifestContent), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remote dependencies that are not vendored and have no chart cache should fail instead of panicking
+func TestRemoteDependencyWithoutCache(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	_, err := RenderHelmChart(false, consts.RemoteDependencies, opt)
+	assert.NotNil(t, err)
+
+	_, err = RenderHelmChartWithOptions(false, consts.RemoteDependencies, opt, &HelmOptions{ChartCacheDir: t.TempDir()})
+	assert.NotNil(t, err)
+}
+
+// Remote dependencies are resolved to the highest matching archive of the chart cache
+func TestRemoteDependencyFromCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	packageRemoteChart(t, "1.0.0", cacheDir)
+	packageRemoteChart(t, "1.1.0", cacheDir)
+	packageRemoteChart(t, "2.0.0", cacheDir)
+
+	helmOpts := &HelmOptions{ChartCacheDir: cacheDir}
+	assert.True(t, renderedWith(t, consts.RemoteDependencies, helmOpts, "name: test-release-umbrella"))
+	assert.True(t, renderedWith(t, consts.RemoteDependencies, helmOpts, "name: test-release-remote-1-1-0"))
+	assert.True(t, renderedWith(t, consts.RemoteDependencies, helmOpts, "replicas: 2"))
+}
+
+// Chart.lock pins the version resolved from the chart cache
+func TestRemoteDependencyFromLock(t *testing.T) {
+	chartDir := t.TempDir()
+	assert.Nil(t, os.CopyFS(chartDir, os.DirFS(consts.RemoteDependencies)))
+	err := os.WriteFile(filepath.Join(chartDir, "Chart.lock"), []byte(`dependencies:
+- name: remotechart
+  repository: https://charts.example.com
+  version: 1.0.0
+digest: sha256:0000000000000000000000000000000000000000000000000000000000000000
+generated: "2024-01-01T00:00:00Z"
+`), 0644)
+	assert.Nil(t, err)
+
+	cacheDir := t.TempDir()
+	packageRemoteChart(t, "1.0.0", cacheDir)
+	packageRemoteChart(t, "1.1.0", cacheDir)
+
+	assert.True(t, renderedWith(t, chartDir, &HelmOptions{ChartCacheDir: cacheDir}, "name: test-release-remote-1-0-0"))
+}
+
+// Remote dependencies vendored as archives in charts/ need no chart cache
+func TestRemoteDependencyVendored(t *testing.T) {
+	chartDir := t.TempDir()
+	assert.Nil(t, os.CopyFS(chartDir, os.DirFS(consts.RemoteDependencies)))
+	packageRemoteChart(t, "1.0.0", filepath.Join(chartDir, "charts"))
+
+	assert.True(t, renderedWith(t, chartDir, nil, "name: test-release-remote-1-0-0"))
+}
+
+// Remote dependencies can be resolved from a file-served repository index
+func TestRemoteDependencyFromIndex(t *testing.T) {
+	cacheDir := t.TempDir()
+	packageRemoteChart(t, "1.2.0", filepath.Join(cacheDir, "archives"))
+	err := os.WriteFile(filepath.Join(cacheDir, "index.yaml"), []byte(`apiVersion: v1
+entries:
+  remotechart:
+  - name: remotechart
+    version: 1.2.0
+    urls:
+    - archives/remotechart-1.2.0.tgz
+  - name: remotechart
+    version: 1.3.0
+    urls:
+    - https://charts.example.com/remotechart-1.3.0.tgz
+`), 0644)
+	assert.Nil(t, err)
+
+	assert.True(t, renderedWith(t, consts.RemoteDependencies, &HelmOptions{ChartCacheDir: cacheDir}, "name: test-release-remote-1-2-0"))
+}
+
+// Dependencies disabled by their condition or tags are not rendered, like helm install
+func TestDependencyConditionAndTags(t *testing.T) {
+	chartDir := t.TempDir()
+	assert.Nil(t, os.CopyFS(chartDir, os.DirFS(consts.RemoteDependencies)))
+	err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(`apiVersion: v2
+name: umbrellachart
+description: A Helm chart with a dependency on a remote repository
+version: 0.1.0
+dependencies:
+  - name: remotechart
+    version: "^1.0.0"
+    repository: "https://charts.example.com"
+    condition: remotechart.enabled
+    tags:
+      - remote
+`), 0644)
+	assert.Nil(t, err)
+
+	cacheDir := t.TempDir()
+	packageRemoteChart(t, "1.0.0", cacheDir)
+
+	assert.True(t, renderedWith(t, chartDir, &HelmOptions{ChartCacheDir: cacheDir}, "name: test-release-remote-1-0-0"))
+	assert.False(t, renderedWith(t, chartDir, &HelmOptions{ChartCacheDir: cacheDir, Values: []string{"remotechart.enabled=false"}}, "name: test-release-remote-1-0-0"))
+	assert.False(t, renderedWith(t, chartDir, &HelmOptions{ChartCacheDir: cacheDir, Values: []string{"tags.remote=false"}}, "name: test-release-remote-1-0-0"))
+	// the condition takes precedence over the tags
+	assert.True(t, renderedWith(t, chartDir, &HelmOptions{ChartCacheDir: cacheDir, Values: []string{"tags.remote=false", "remotechart.enabled=true"}}, "name: test-release-remote-1-0-0"))
+}
+
+// A file:// dependency is rendered from its path even when a copy of the chart is vendored in charts/
+func TestFileDependencyOverridesVendored(t *testing.T) {
+	rootDir := t.TempDir()
+	chartDir := filepath.Join(rootDir, "mainchart")
+	localDir := filepath.Join(rootDir, "subchart2")
+	assert.Nil(t, os.CopyFS(chartDir, os.DirFS(consts.Subcharts)))
+	assert.Nil(t, os.CopyFS(localDir, os.DirFS(filepath.Join(chartDir, "charts", "subchart2"))))
+	assert.Nil(t, os.WriteFile(filepath.Join(localDir, "values.yaml"), []byte("replicaCount: 7\nimage:\n  repository: nginx\n  tag: stable\n"), 0644))
+
+	chartYaml := `apiVersion: v2
+name: mainchart
+description: A Helm chart with subcharts
+version: 0.1.0
+dependencies:
+  - name: subchart2
+    version: 0.1.0
+    repository: "file://../subchart2"
+`
+	assert.Nil(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYaml), 0644))
+	assert.True(t, renderedWith(t, chartDir, nil, "replicas: 7"))
+	assert.False(t, renderedWith(t, chartDir, nil, "replicas: 3"))
+
+	// a disabled file:// dependency is rendered from neither its path nor charts/
+	chartYaml += "    condition: subchart2.enabled\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYaml), 0644))
+	assert.False(t, renderedWith(t, chartDir, &HelmOptions{Values: []string{"subchart2.enabled=false"}}, "-subchart2"))
+}
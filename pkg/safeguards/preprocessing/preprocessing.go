@@ -2,7 +2,9 @@ package preprocessing
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
+	"strings"
 
 	sgTypes "github.com/Azure/draft/pkg/safeguards/types"
 	log "github.com/sirupsen/logrus"
@@ -18,10 +20,22 @@ import (
 
 // Given a Helm chart directory or file, renders all templates and writes them to the specified directory
 func RenderHelmChart(isFile bool, mainChartPath string, opt chartutil.ReleaseOptions) ([]sgTypes.ManifestFile, error) {
+	return RenderHelmChartWithOptions(isFile, mainChartPath, opt, nil)
+}
+
+// RenderHelmChartWithOptions renders a Helm chart like RenderHelmChart, layering the values files and --set overrides of
+// helmOpts on top of the main chart's values.yaml in the same order helm install applies them and resolving remote
+// dependencies that are not vendored in charts/ from the chart cache of helmOpts
+func RenderHelmChartWithOptions(isFile bool, mainChartPath string, opt chartutil.ReleaseOptions, helmOpts *HelmOptions) ([]sgTypes.ManifestFile, error) {
 	if isFile { // Get the directory that the Chart.yaml lives in
 		mainChartPath = filepath.Dir(mainChartPath)
 	}
 
+	overrides, err := helmOpts.mergeValues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load override values: %w", err)
+	}
+
 	mainChart, err := loader.Load(mainChartPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load main chart: %s", err)
@@ -29,24 +43,75 @@ func RenderHelmChart(isFile bool, mainChartPath string, opt chartutil.ReleaseOpt
 
 	loadedCharts := make(map[string]*chart.Chart) // map of chart path to chart object
 	loadedCharts[mainChartPath] = mainChart
+	chartOverrides := make(map[string]map[string]interface{}) // map of chart path to the overrides scoped to the chart
+	chartOverrides[mainChartPath] = overrides
 
 	// Load subcharts and dependencies
+	var chartCacheDir string
+	if helmOpts != nil {
+		chartCacheDir = helmOpts.ChartCacheDir
+	}
+	fileDependencyPaths := make(map[string]string) // map of file:// dependency name, or alias, to chart path
 	for _, dep := range mainChart.Metadata.Dependencies {
-		// Resolve the chart path based on the main chart's directory
-		chartPath := filepath.Join(mainChartPath, dep.Repository[len("file://"):])
-		chartPath = filepath.Clean(chartPath)
+		if strings.HasPrefix(dep.Repository, fileRepositoryPrefix) {
+			// Resolve the chart path based on the main chart's directory
+			chartPath := filepath.Join(mainChartPath, strings.TrimPrefix(dep.Repository, fileRepositoryPrefix))
+			chartPath = filepath.Clean(chartPath)
+
+			subChart, err := loader.Load(chartPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load chart: %s", err)
+			}
+			// the file:// chart replaces a copy vendored in charts/. A second copy is added to the main chart only to process
+			// its condition and tags, as a chart added as a dependency renders as part of its parent.
+			dependencyChart, err := loader.Load(chartPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load chart: %s", err)
+			}
+			removeDependency(mainChart, dep.Name)
+			mainChart.AddDependency(dependencyChart)
+			fileDependencyPaths[dependencyName(dep)] = chartPath
+			loadedCharts[chartPath] = subChart
+			chartOverrides[chartPath] = subchartOverrides(overrides, dep)
+			continue
+		}
+
+		// dependencies vendored in charts/, as directories or .tgz archives, are rendered along with the main chart
+		if isVendoredDependency(mainChart, dep) {
+			log.Debugf("dependency %s is vendored in %s", dep.Name, mainChartPath)
+			continue
+		}
 
-		subChart, err := loader.Load(chartPath)
+		subChart, err := loadCachedDependency(chartCacheDir, dep, lockedVersion(mainChart, dep))
 		if err != nil {
-			return nil, fmt.Errorf("failed to load chart: %s", err)
+			return nil, fmt.Errorf("failed to resolve dependency %s from %s: %w", dep.Name, dep.Repository, err)
 		}
-		loadedCharts[chartPath] = subChart
+		mainChart.AddDependency(subChart)
+	}
+
+	// drop the dependencies disabled by their condition or tags, and alias the others, like helm install does
+	if err := chartutil.ProcessDependencies(mainChart, overrides); err != nil {
+		return nil, fmt.Errorf("failed to process dependencies: %w", err)
+	}
+	// enabled file:// dependencies are rendered on their own rather than with the main chart
+	var mainDependencies []*chart.Chart
+	for _, dep := range mainChart.Dependencies() {
+		if _, ok := fileDependencyPaths[dep.Name()]; ok {
+			delete(fileDependencyPaths, dep.Name())
+			continue
+		}
+		mainDependencies = append(mainDependencies, dep)
+	}
+	mainChart.SetDependencies(mainDependencies...)
+	for name, chartPath := range fileDependencyPaths {
+		log.Debugf("dependency %s is disabled", name)
+		delete(loadedCharts, chartPath)
 	}
 
 	var manifestFiles []sgTypes.ManifestFile
 	for chartPath, chart := range loadedCharts {
 		valuesPath := filepath.Join(chartPath, "values.yaml") // Enforce that values.yaml must be at same level as Chart.yaml
-		mergedValues, err := getValues(chart, valuesPath, opt, filepath.Base(mainChartPath))
+		mergedValues, err := getValues(chart, valuesPath, opt, filepath.Base(mainChartPath), chartOverrides[chartPath])
 		if err != nil {
 			return nil, fmt.Errorf("failed to load values: %s", err)
 		}
@@ -59,7 +124,7 @@ func RenderHelmChart(isFile bool, mainChartPath string, opt chartutil.ReleaseOpt
 		// Convert renderd files to []byte
 		for renderedPath, content := range renderedFiles {
 			byteContent := []byte(content)
-			manifestFiles = append(manifestFiles, sgTypes.ManifestFile{Name: filepath.Base(renderedPath), ManifestContent: byteContent})
+			manifestFiles = append(manifestFiles, sgTypes.ManifestFile{Name: filepath.Base(renderedPath), ManifestContent: byteContent, Source: renderedPath, Rendered: true})
 		}
 	}
 
@@ -68,14 +133,51 @@ func RenderHelmChart(isFile bool, mainChartPath string, opt chartutil.ReleaseOpt
 
 // Given a kustomization manifest file within kustomizationPath, RenderKustomizeManifest will return render templates
 func RenderKustomizeManifest(kustomizationPath string) ([]sgTypes.ManifestFile, error) {
-	log.Debugf("Rendering kustomization.yaml...")
+	return RenderKustomizeManifestWithOptions(kustomizationPath, nil)
+}
+
+// RenderKustomizeManifestWithOptions renders a kustomization like RenderKustomizeManifest. Given a kustomize root without
+// a kustomization of its own, such as draft's base plus overlays layout, it renders every overlay under overlays/, or the
+// overlays chosen in kustomizeOpts, prefixing the name of each rendered manifest with its overlay.
+func RenderKustomizeManifestWithOptions(kustomizationPath string, kustomizeOpts *KustomizeOptions) ([]sgTypes.ManifestFile, error) {
 	if IsYAML(kustomizationPath) {
 		kustomizationPath = filepath.Dir(kustomizationPath)
 	}
+	if kustomizeOpts == nil {
+		kustomizeOpts = &KustomizeOptions{}
+	}
+
+	overlays, err := selectOverlays(kustomizationPath, kustomizeOpts.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlays) == 0 {
+		return renderKustomization(kustomizationPath, kustomizeOpts.loadRestrictions())
+	}
+
+	var manifestFiles []sgTypes.ManifestFile
+	for _, overlay := range overlays {
+		log.Debugf("Rendering overlay %s...", overlay)
+		overlayFiles, err := renderKustomization(filepath.Join(kustomizationPath, overlaysDirName, overlay), kustomizeOpts.loadRestrictions())
+		if err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", overlay, err)
+		}
+		for _, f := range overlayFiles {
+			f.Name = path.Join(overlay, f.Name)
+			manifestFiles = append(manifestFiles, f)
+		}
+	}
+
+	return manifestFiles, nil
+}
+
+// renderKustomization runs krusty on a single kustomization directory
+func renderKustomization(kustomizationPath string, loadRestrictions types.LoadRestrictions) ([]sgTypes.ManifestFile, error) {
+	log.Debugf("Rendering kustomization.yaml...")
 
 	options := &krusty.Options{
 		Reorder:          "none",
-		LoadRestrictions: types.LoadRestrictionsRootOnly,
+		LoadRestrictions: loadRestrictions,
 		PluginConfig:     &types.PluginConfig{},
 	}
 	k := krusty.MakeKustomizer(options)
@@ -99,6 +201,8 @@ func RenderKustomizeManifest(kustomizationPath string) ([]sgTypes.ManifestFile,
 		manifestFiles = append(manifestFiles, sgTypes.ManifestFile{
 			Name:            res.GetName(),
 			ManifestContent: yamlRes,
+			Source:          fmt.Sprintf("%s#%s", kustomizationPath, res.CurId()),
+			Rendered:        true,
 		})
 	}
 
@@ -8,10 +8,139 @@ import (
 	"gopkg.in/yaml.v3"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+	kustomizeTypes "sigs.k8s.io/kustomize/api/types"
 )
 
-// Returns values from values.yaml and release options specified in values.yaml
-func getValues(chart *chart.Chart, valuesPath string, opt chartutil.ReleaseOptions, dirName string) (chartutil.Values, error) {
+const overlaysDirName = "overlays"
+
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// HelmOptions holds the settings used when rendering a Helm chart
+type HelmOptions struct {
+	ValueFiles    []string // -f/--values, applied in order, values nested under a dependency name apply to the dependency
+	Values        []string // --set, applied in order after the values files
+	ChartCacheDir string   // local directory of chart archives, unpacked charts or a file-served index.yaml to resolve remote dependencies from
+}
+
+// KustomizeOptions holds the settings used when rendering a kustomization
+type KustomizeOptions struct {
+	Overlays              []string // names of the overlays under overlays/ to render, all overlays when empty
+	RelaxLoadRestrictions bool     // allow kustomizations to load files outside of their own directory, like shared components
+}
+
+func (k *KustomizeOptions) loadRestrictions() kustomizeTypes.LoadRestrictions {
+	if k.RelaxLoadRestrictions {
+		return kustomizeTypes.LoadRestrictionsNone
+	}
+	return kustomizeTypes.LoadRestrictionsRootOnly
+}
+
+// selectOverlays returns the overlays of a kustomize root to render. Without chosen overlays, every directory under
+// overlays/ holding a kustomization is returned, unless the root is a kustomization itself.
+func selectOverlays(kustomizeRoot string, chosen []string) ([]string, error) {
+	overlaysPath := filepath.Join(kustomizeRoot, overlaysDirName)
+
+	if len(chosen) > 0 {
+		for _, overlay := range chosen {
+			if !HasKustomization(filepath.Join(overlaysPath, overlay)) {
+				return nil, fmt.Errorf("overlay %s not found in %s", overlay, overlaysPath)
+			}
+		}
+		return chosen, nil
+	}
+
+	if HasKustomization(kustomizeRoot) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(overlaysPath)
+	if err != nil {
+		return nil, fmt.Errorf("no kustomization or overlays found in %s", kustomizeRoot)
+	}
+	var overlays []string
+	for _, entry := range entries {
+		if entry.IsDir() && HasKustomization(filepath.Join(overlaysPath, entry.Name())) {
+			overlays = append(overlays, entry.Name())
+		}
+	}
+	if len(overlays) == 0 {
+		return nil, fmt.Errorf("no overlays found in %s", overlaysPath)
+	}
+
+	return overlays, nil
+}
+
+// HasKustomization reports whether dir holds a kustomization file
+func HasKustomization(dir string) bool {
+	for _, name := range kustomizationFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasKustomizeOverlays reports whether dir is a kustomize root with overlays, like draft's base plus overlays layout
+func HasKustomizeOverlays(dir string) bool {
+	entries, err := os.ReadDir(filepath.Join(dir, overlaysDirName))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && HasKustomization(filepath.Join(dir, overlaysDirName, entry.Name())) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeValues reads the values files and --set overrides into a single map, later values taking precedence
+func (v *HelmOptions) mergeValues() (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	if v == nil {
+		return merged, nil
+	}
+
+	for _, valuesFile := range v.ValueFiles {
+		content, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", valuesFile, err)
+		}
+
+		current := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", valuesFile, err)
+		}
+		merged = chartutil.CoalesceTables(current, merged)
+	}
+
+	for _, value := range v.Values {
+		if err := strvals.ParseInto(value, merged); err != nil {
+			return nil, fmt.Errorf("failed to parse --set value %s: %w", value, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// subchartOverrides returns the overrides of a subchart rendered on its own, the values nested under its name, or alias,
+// in the overrides of the main chart along with the global values
+func subchartOverrides(overrides map[string]interface{}, dep *chart.Dependency) map[string]interface{} {
+	scoped := map[string]interface{}{}
+	if values, ok := overrides[dependencyName(dep)].(map[string]interface{}); ok {
+		for k, v := range values {
+			scoped[k] = v
+		}
+	}
+	if global, ok := overrides[chartutil.GlobalKey]; ok {
+		scoped[chartutil.GlobalKey] = global
+	}
+	return scoped
+}
+
+// Returns values from values.yaml with the given overrides applied and release options specified in the values
+func getValues(chart *chart.Chart, valuesPath string, opt chartutil.ReleaseOptions, dirName string, overrides map[string]interface{}) (chartutil.Values, error) {
 	// Load values file
 	valuesFile, err := os.ReadFile(valuesPath)
 	if err != nil {
@@ -22,6 +151,10 @@ func getValues(chart *chart.Chart, valuesPath string, opt chartutil.ReleaseOptio
 	if err := yaml.Unmarshal(valuesFile, &vals); err != nil {
 		return nil, fmt.Errorf("failed to parse values.yaml: %s", err)
 	}
+	if len(overrides) > 0 {
+		// CoalesceTables keeps the values already in its destination, so the overrides take precedence
+		vals = chartutil.CoalesceTables(overrides, vals)
+	}
 
 	mergedValues, err := getReleaseOptions(chart, vals, opt, dirName)
 	return mergedValues, err
@@ -2,6 +2,8 @@ package preprocessing
 
 import (
 	"bytes"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	consts "github.com/Azure/draft/pkg/safeguards/types"
@@ -73,6 +75,21 @@ func TestRenderHelmChartWithFlags_Valid(t *testing.T) {
 	}
 }
 
+// Rendered Helm manifests keep their template path within the chart, including the subchart they were rendered from
+func TestRenderHelmChartSources(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+
+	manifestFiles, err := RenderHelmChart(false, consts.Subcharts, opt)
+	assert.Nil(t, err)
+
+	var sources []string
+	for _, m := range manifestFiles {
+		assert.True(t, strings.HasSuffix(m.Source, "/templates/"+m.Name))
+		sources = append(sources, m.Source)
+	}
+	assert.Contains(t, sources, "mainchart/templates/maindeployment.yaml")
+}
+
 // Should successfully render a Helm chart with sub charts and be able to render subchart separately within a helm chart
 func TestSubCharts(t *testing.T) {
 	var opt chartutil.ReleaseOptions
@@ -185,3 +202,92 @@ func TestRenderKustomizeManifest_Valid(t *testing.T) {
 	_, err := RenderKustomizeManifest(consts.KustomizationPath)
 	assert.Nil(t, err)
 }
+
+// Test rendering a valid Helm chart with values files and --set overrides layered on top of values.yaml
+func TestRenderHelmChartWithOptions(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+
+	helmOpts := &HelmOptions{
+		ValueFiles: []string{consts.OverrideValuesFile},
+		Values:     []string{"image.tag=v2"},
+	}
+	manifestFiles, err := RenderHelmChartWithOptions(false, consts.ChartPath, opt, helmOpts)
+	assert.Nil(t, err)
+
+	var deployment string
+	for _, m := range manifestFiles {
+		if m.Name == "deployment.yaml" {
+			deployment = string(m.ManifestContent)
+		}
+	}
+	assert.Contains(t, deployment, "replicas: 3")
+	assert.Contains(t, deployment, "image: nginx:v2")
+	assert.Contains(t, deployment, "name: test-release-deployment")
+
+	// missing values files and malformed --set overrides should error
+	_, err = RenderHelmChartWithOptions(false, consts.ChartPath, opt, &HelmOptions{ValueFiles: []string{"does-not-exist.yaml"}})
+	assert.NotNil(t, err)
+	_, err = RenderHelmChartWithOptions(false, consts.ChartPath, opt, &HelmOptions{Values: []string{"image.tag"}})
+	assert.NotNil(t, err)
+}
+
+// Overrides nested under the name of a file:// dependency reach the dependency, which is rendered on its own
+func TestRenderHelmChartWithOptionsSubchartOverrides(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+
+	helmOpts := &HelmOptions{Values: []string{"replicaCount=4", "subchart2.replicaCount=5"}}
+	manifestFiles, err := RenderHelmChartWithOptions(true, consts.DirectPath_ToSubchartYaml, opt, helmOpts)
+	assert.Nil(t, err)
+
+	deployments := make(map[string]string)
+	for _, m := range manifestFiles {
+		deployments[m.Name] = string(m.ManifestContent)
+	}
+	assert.Contains(t, deployments["deployment1.yaml"], "replicas: 4")
+	assert.Contains(t, deployments["deployment2.yaml"], "replicas: 5")
+}
+
+// Test rendering every overlay, or the chosen overlays, of a kustomize root
+func TestRenderKustomizeOverlays(t *testing.T) {
+	manifestFiles, err := RenderKustomizeManifestWithOptions(consts.KustomizeRoot, nil)
+	assert.Nil(t, err)
+
+	overlays := make(map[string]bool)
+	for _, m := range manifestFiles {
+		overlay, _, found := strings.Cut(m.Name, "/")
+		assert.True(t, found)
+		overlays[overlay] = true
+	}
+	assert.Equal(t, map[string]bool{"production": true, "staging": true}, overlays)
+
+	manifestFiles, err = RenderKustomizeManifestWithOptions(consts.KustomizeRoot, &KustomizeOptions{Overlays: []string{"staging"}})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, manifestFiles)
+	for _, m := range manifestFiles {
+		assert.True(t, strings.HasPrefix(m.Name, "staging/"))
+		// rendered manifests are located by their overlay and resource id
+		assert.True(t, m.Rendered)
+		assert.True(t, strings.HasPrefix(m.Source, filepath.Join(consts.KustomizeRoot, "overlays", "staging")+"#"))
+	}
+
+	_, err = RenderKustomizeManifestWithOptions(consts.KustomizeRoot, &KustomizeOptions{Overlays: []string{"missing"}})
+	assert.NotNil(t, err)
+
+	// a kustomization is rendered directly
+	manifestFiles, err = RenderKustomizeManifestWithOptions(consts.KustomizationPath, nil)
+	assert.Nil(t, err)
+	for _, m := range manifestFiles {
+		assert.NotContains(t, m.Name, "/")
+	}
+}
+
+// Overlays loading files outside of their directory need relaxed load restrictions
+func TestRenderKustomizeRelaxedLoadRestrictions(t *testing.T) {
+	_, err := RenderKustomizeManifestWithOptions(consts.KustomizeSharedRoot, nil)
+	assert.NotNil(t, err)
+
+	manifestFiles, err := RenderKustomizeManifestWithOptions(consts.KustomizeSharedRoot, &KustomizeOptions{RelaxLoadRestrictions: true})
+	assert.Nil(t, err)
+	assert.Len(t, manifestFiles, 1)
+	assert.Equal(t, "dev/dev-my-app", manifestFiles[0].Name)
+}
@@ -0,0 +1,318 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "draft"
+	toolURI      = "https://github.com/Azure/draft"
+)
+
+// Formats lists the supported report formats
+var Formats = []string{FormatJSON, FormatSARIF, FormatJUnit}
+
+// Write serializes the manifest results to w in the given format
+func Write(w io.Writer, format string, results []types.ManifestResult) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, results)
+	case FormatSARIF:
+		root, err := sourceRoot()
+		if err != nil {
+			return err
+		}
+		return writeSARIF(w, results, root)
+	case FormatJUnit:
+		return writeJUnit(w, results)
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+func writeJSON(w io.Writer, results []types.ManifestResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return fmt.Errorf("encoding json report: %w", err)
+	}
+	return nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// kustomizationFileNames are the names kustomize reads a kustomization from
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// sourceRoot returns the root of the git repository holding the working directory, the base code scanning resolves
+// SARIF locations against, or the working directory outside of a repository
+func sourceRoot() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err = os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return wd, nil
+		}
+	}
+}
+
+// sarifURI returns the location of the file of a violation relative to root. Kustomize resources, whose file is their
+// kustomization path and resource id, are located at their kustomization file, so their line within the built resource
+// does not apply and exact is false.
+func sarifURI(root, file string) (uri string, exact bool) {
+	path, _, isResource := strings.Cut(file, "#")
+	if isResource {
+		for _, name := range kustomizationFileNames {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				path = filepath.Join(path, name)
+				break
+			}
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path), !isResource
+}
+
+func writeSARIF(w io.Writer, results []types.ManifestResult, root string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	addResult := func(v types.Violation) {
+		if !rules[v.Constraint] {
+			rules[v.Constraint] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               v.Constraint,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("AKS deployment safeguard %s", v.Constraint)},
+			})
+		}
+
+		uri, exact := sarifURI(root, v.File)
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}
+		if exact && v.Line > 0 {
+			location.Region = &sarifRegion{StartLine: v.Line}
+		}
+		result := sarifResult{
+			RuleID:    v.Constraint,
+			Level:     sarifLevel(v.Severity),
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", objectName(v), v.Message)},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		}
+		if v.Reason != "" {
+			result.Suppressions = []sarifSuppression{{Kind: "inSource", Justification: v.Reason}}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	for _, r := range results {
+		for _, v := range r.Violations {
+			addResult(v)
+		}
+		for _, v := range r.Suppressed {
+			addResult(v)
+		}
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("encoding sarif report: %w", err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes one test suite per manifest with a test case per violation, failing unless the violation is a warning,
+// and a skipped test case per suppressed violation, or a single passing test case when the manifest has no violations
+func writeJUnit(w io.Writer, results []types.ManifestResult) error {
+	suites := junitTestSuites{Name: toolName + " validate"}
+
+	for _, r := range results {
+		suite := junitTestSuite{Name: r.Name}
+		for _, v := range r.Violations {
+			location := v.File
+			if v.Line > 0 {
+				location = fmt.Sprintf("%s:%d", v.File, v.Line)
+			}
+			testCase := junitTestCase{
+				Name:      fmt.Sprintf("%s %s", objectName(v), v.Constraint),
+				ClassName: r.Name,
+			}
+			text := fmt.Sprintf("%s\n%s", location, v.Message)
+			// warnings are reported without failing the test case
+			if v.Severity == types.SeverityWarning {
+				testCase.SystemOut = fmt.Sprintf("warning: %s", text)
+			} else {
+				testCase.Failure = &junitFailure{Message: v.Message, Type: v.Constraint, Text: text}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		for _, v := range r.Suppressed {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fmt.Sprintf("%s %s", objectName(v), v.Constraint),
+				ClassName: r.Name,
+				Skipped:   &junitSkipped{Message: fmt.Sprintf("suppressed: %s", v.Reason)},
+			})
+			suite.Skipped++
+		}
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{Name: "no violations", ClassName: r.Name})
+		}
+		suite.Tests = len(suite.Cases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing junit report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("encoding junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func sarifLevel(severity string) string {
+	if severity == types.SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// objectName formats a violating object as Kind/name or Kind/namespace/name
+func objectName(v types.Violation) string {
+	if v.Namespace == "" {
+		return fmt.Sprintf("%s/%s", v.Kind, v.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", v.Kind, v.Namespace, v.Name)
+}
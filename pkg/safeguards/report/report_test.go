@@ -0,0 +1,135 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+var testObjectKey = types.ObjectKey("apps/v1", "Deployment", "default", "my-app")
+
+// testResults are shaped like the results of the validator, which keys object violations by object identity and counts
+// every violation
+var testResults = []types.ManifestResult{
+	{
+		Name:             "deployment.yaml",
+		Source:           "deployment.yaml",
+		ObjectViolations: map[string][]string{testObjectKey: {"missing probes", "missing limits"}},
+		ViolationsCount:  2,
+		Violations: []types.Violation{
+			{Constraint: "container-enforce-probes", Severity: types.SeverityError, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "my-app", Message: "missing probes", File: "deployment.yaml", Line: 3},
+			{Constraint: "container-resource-limits", Severity: types.SeverityWarning, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "my-app", Message: "missing limits", File: "deployment.yaml", Line: 3},
+		},
+		Suppressed: []types.Violation{
+			{Constraint: "pod-enforce-antiaffinity", Severity: types.SeverityError, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "my-app", Message: "missing anti-affinity", File: "deployment.yaml", Line: 3, Reason: "single replica"},
+		},
+	},
+	{
+		Name:             "service.yaml",
+		ObjectViolations: map[string][]string{},
+	},
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, FormatJSON, testResults))
+
+	var decoded []types.ManifestResult
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, testResults[0].Violations, decoded[0].Violations)
+	assert.Equal(t, 2, decoded[0].ViolationsCount)
+	assert.Equal(t, []string{"missing probes", "missing limits"}, decoded[0].ObjectViolations[testObjectKey])
+	assert.Equal(t, "service.yaml", decoded[1].Name)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	assert.Nil(t, writeSARIF(&buf, testResults, wd))
+
+	var decoded sarifLog
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, sarifVersion, decoded.Version)
+	assert.Len(t, decoded.Runs, 1)
+
+	run := decoded.Runs[0]
+	assert.Len(t, run.Tool.Driver.Rules, 3)
+	assert.Len(t, run.Results, 3)
+	assert.Equal(t, "container-enforce-probes", run.Results[0].RuleID)
+	assert.Equal(t, "Deployment/default/my-app: missing probes", run.Results[0].Message.Text)
+	assert.Equal(t, "deployment.yaml", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 3, run.Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Empty(t, run.Results[0].Suppressions)
+	assert.Equal(t, "single replica", run.Results[2].Suppressions[0].Justification)
+}
+
+func TestSarifURI(t *testing.T) {
+	root := t.TempDir()
+	overlay := filepath.Join(root, "overlays", "production")
+	assert.Nil(t, os.MkdirAll(overlay, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte("resources: []\n"), 0644))
+
+	uri, exact := sarifURI(root, filepath.Join(root, "manifests", "deployment.yaml"))
+	assert.Equal(t, "manifests/deployment.yaml", uri)
+	assert.True(t, exact)
+
+	// kustomize resources are located at their kustomization file
+	uri, exact = sarifURI(root, overlay+"#apps_v1_Deployment|default|my-app")
+	assert.Equal(t, "overlays/production/kustomization.yaml", uri)
+	assert.False(t, exact)
+
+	// files outside of the root keep their path
+	outside := filepath.Join(t.TempDir(), "deployment.yaml")
+	uri, _ = sarifURI(root, outside)
+	assert.Equal(t, filepath.ToSlash(outside), uri)
+}
+
+func TestWriteSARIFRepoRelative(t *testing.T) {
+	root := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "manifests"), 0755))
+	t.Chdir(filepath.Join(root, "manifests"))
+
+	results := []types.ManifestResult{{
+		Name:       "deployment.yaml",
+		Violations: []types.Violation{{Constraint: "container-enforce-probes", File: filepath.Join(root, "manifests", "deployment.yaml"), Line: 3}},
+	}}
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, FormatSARIF, results))
+
+	var decoded sarifLog
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "manifests/deployment.yaml", decoded.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, Write(&buf, FormatJUnit, testResults))
+
+	var decoded junitTestSuites
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, 4, decoded.Tests)
+	assert.Equal(t, 1, decoded.Failures)
+	assert.Len(t, decoded.Suites, 2)
+	assert.Equal(t, "container-enforce-probes", decoded.Suites[0].Cases[0].Failure.Type)
+	assert.Nil(t, decoded.Suites[0].Cases[1].Failure)
+	assert.Contains(t, decoded.Suites[0].Cases[1].SystemOut, "missing limits")
+	assert.Equal(t, 1, decoded.Suites[0].Skipped)
+	assert.Equal(t, "suppressed: single replica", decoded.Suites[0].Cases[2].Skipped.Message)
+	assert.Nil(t, decoded.Suites[1].Cases[0].Failure)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NotNil(t, Write(&buf, "yaml", testResults))
+}
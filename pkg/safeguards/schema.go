@@ -0,0 +1,155 @@
+package safeguards
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+//go:generate go run generate_schemas.go 1.27.0
+
+// EmbeddedSchemaVersion is the Kubernetes version of the schemas embedded in draft, generated from the
+// api/openapi-spec/swagger.json of the release, so the kinds draft generates validate offline
+const EmbeddedSchemaVersion = "1.27.0"
+
+// embeddedSchemaLocation is the directory of the schema cache the embedded schemas are written to for kubeconform
+const embeddedSchemaLocation = "embedded"
+
+//go:embed schemas
+var embeddedSchemas embed.FS
+
+// SchemaOptions configures the strict Kubernetes schema validation of manifests
+type SchemaOptions struct {
+	KubernetesVersion    string   // the Kubernetes version whose schemas are used, e.g. 1.30.0, defaults to EmbeddedSchemaVersion
+	SchemaLocations      []string // local kubernetes-json-schema directories or schema URL templates, defaults to the embedded schemas then the kubernetes-json-schema repository
+	CacheDir             string   // where downloaded schemas are cached so later validations work offline, defaults to the user cache directory
+	IgnoreMissingSchemas bool     // skip objects without a schema, like custom resources, instead of reporting them
+}
+
+// DefaultSchemaCacheDir returns the directory downloaded Kubernetes schemas are cached in when no cache directory is given
+func DefaultSchemaCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "draft", "schemas"), nil
+}
+
+// GetSchemaResults validates every object of the manifest files against the Kubernetes schemas in strict mode, rejecting
+// unknown fields. Schema errors are reported as kubernetes-schema violations in one result per manifest file.
+func GetSchemaResults(manifestFiles []types.ManifestFile, opts SchemaOptions) ([]types.ManifestResult, error) {
+	if opts.CacheDir == "" {
+		cacheDir, err := DefaultSchemaCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.CacheDir = cacheDir
+	}
+	if err := os.MkdirAll(opts.CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating schema cache: %w", err)
+	}
+	if opts.KubernetesVersion == "" {
+		opts.KubernetesVersion = EmbeddedSchemaVersion
+	}
+	if len(opts.SchemaLocations) == 0 {
+		// kinds missing from the embedded schemas, or other versions, are downloaded
+		opts.SchemaLocations = []string{"default"}
+		if opts.KubernetesVersion == EmbeddedSchemaVersion {
+			location, err := writeEmbeddedSchemas(opts.CacheDir)
+			if err != nil {
+				return nil, err
+			}
+			opts.SchemaLocations = []string{location, "default"}
+		}
+	}
+
+	v, err := validator.New(opts.SchemaLocations, validator.Opts{
+		Cache:                opts.CacheDir,
+		KubernetesVersion:    opts.KubernetesVersion,
+		Strict:               true,
+		IgnoreMissingSchemas: opts.IgnoreMissingSchemas,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initializing schema validator: %w", err)
+	}
+
+	results := make([]types.ManifestResult, 0, len(manifestFiles))
+	for _, m := range manifestFiles {
+		file := m.Source
+		if file == "" {
+			file = m.Name
+		}
+
+		objectPositions := getObjectPositions(m.ManifestContent)
+		result := types.ManifestResult{Name: m.Name, Source: m.Source, ObjectViolations: make(map[string][]string), Violations: []types.Violation{}}
+		for _, res := range v.Validate(file, io.NopCloser(bytes.NewReader(m.ManifestContent))) {
+			if res.Status != validator.Invalid && res.Status != validator.Error {
+				continue
+			}
+
+			violation := types.Violation{
+				Constraint:     types.Constraint_Schema,
+				ConstraintName: types.Constraint_Schema,
+				Severity:       types.SeverityError,
+				File:           file,
+			}
+			if sig, err := res.Resource.Signature(); err == nil {
+				violation.APIVersion = sig.Version
+				violation.Kind = sig.Kind
+				violation.Namespace = sig.Namespace
+				violation.Name = sig.Name
+				violation.Document = objectPositions[violation.ObjectKey()].document
+				violation.Line = objectPositions[violation.ObjectKey()].line
+			}
+
+			if len(res.ValidationErrors) == 0 {
+				violation.Message = res.Err.Error()
+				result.AddViolations(violation)
+				continue
+			}
+			for _, ve := range res.ValidationErrors {
+				violation.Message = fmt.Sprintf("%s: %s", ve.Path, ve.Msg)
+				result.AddViolations(violation)
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// writeEmbeddedSchemas writes the embedded schemas into the cache directory, laid out like kubernetes-json-schema, and
+// returns their location. Files already up to date are left untouched.
+func writeEmbeddedSchemas(cacheDir string) (string, error) {
+	location := filepath.Join(cacheDir, embeddedSchemaLocation)
+	err := fs.WalkDir(embeddedSchemas, "schemas", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(location, filepath.FromSlash(path[len("schemas"):]))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		content, err := embeddedSchemas.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if current, err := os.ReadFile(target); err == nil && bytes.Equal(current, content) {
+			return nil
+		}
+		return os.WriteFile(target, content, 0644)
+	})
+	if err != nil {
+		return "", fmt.Errorf("writing embedded schemas: %w", err)
+	}
+	return location, nil
+}
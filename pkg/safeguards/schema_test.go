@@ -0,0 +1,112 @@
+package safeguards
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const (
+	schemaLocation             = "tests/schemas"
+	schemaKubernetesVersion    = "1.30.0"
+	invalidSchemaManifestPath  = "tests/schema/invalid-manifest.yaml"
+	allSuccessManifestFilePath = "tests/all/success/all-success-manifest-1.yaml"
+)
+
+func TestGetSchemaResults(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(invalidSchemaManifestPath, opt)
+	assert.Nil(t, err)
+
+	opts := SchemaOptions{
+		KubernetesVersion: schemaKubernetesVersion,
+		SchemaLocations:   []string{schemaLocation},
+		CacheDir:          t.TempDir(),
+	}
+	results, err := GetSchemaResults(manifestFiles, opts)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, invalidSchemaManifestPath, results[0].Source)
+
+	// the unknown field of the Deployment and the Widget without a schema are reported, the valid Service is not
+	violations := results[0].Violations
+	assert.Len(t, violations, 2)
+	assert.Equal(t, types.Violation{
+		Constraint:     types.Constraint_Schema,
+		ConstraintName: types.Constraint_Schema,
+		Severity:       types.SeverityError,
+		APIVersion:     "apps/v1",
+		Kind:           "Deployment",
+		Name:           "myapp",
+		Message:        "/spec: additional properties 'replica' not allowed",
+		File:           invalidSchemaManifestPath,
+		Document:       1,
+		Line:           11,
+	}, violations[0])
+	assert.Equal(t, "Widget", violations[1].Kind)
+	assert.Contains(t, results[0].ObjectViolations, types.ObjectKey("example.com/v1", "Widget", "", "myapp"))
+	assert.Equal(t, 2, results[0].ViolationsCount)
+
+	// custom resources can be skipped
+	opts.IgnoreMissingSchemas = true
+	results, err = GetSchemaResults(manifestFiles, opts)
+	assert.Nil(t, err)
+	assert.Len(t, results[0].Violations, 1)
+
+	manifestFiles, err = GetManifestFiles(allSuccessManifestFilePath, opt)
+	assert.Nil(t, err)
+	results, err = GetSchemaResults(manifestFiles, opts)
+	assert.Nil(t, err)
+	assert.Empty(t, results[0].Violations)
+}
+
+func TestGetSchemaResultsInvalidOptions(t *testing.T) {
+	_, err := GetSchemaResults([]types.ManifestFile{}, SchemaOptions{SchemaLocations: []string{"{{ .Invalid"}, CacheDir: t.TempDir()})
+	assert.NotNil(t, err)
+}
+
+// The embedded schemas validate the kinds draft generates without downloading anything
+func TestGetSchemaResultsEmbedded(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allSuccessManifestFilePath, opt)
+	assert.Nil(t, err)
+
+	cacheDir := t.TempDir()
+	results, err := GetSchemaResults(manifestFiles, SchemaOptions{CacheDir: cacheDir})
+	assert.Nil(t, err)
+	assert.Empty(t, results[0].Violations)
+	assert.FileExists(t, filepath.Join(cacheDir, embeddedSchemaLocation, "v"+EmbeddedSchemaVersion+"-standalone-strict", "deployment-apps-v1.json"))
+
+	manifestFiles = []types.ManifestFile{{Name: "deployment.yaml", ManifestContent: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+spec:
+  replica: 3
+  selector:
+    matchLabels:
+      app: myapp
+  template:
+    metadata:
+      labels:
+        app: myapp
+    spec:
+      containers:
+        - name: myapp
+          image: myapp:latest
+          resources:
+            limits:
+              cpu: 1
+              memory: 512Mi
+          ports:
+            - containerPort: 80
+`)}}
+	results, err = GetSchemaResults(manifestFiles, SchemaOptions{KubernetesVersion: EmbeddedSchemaVersion, CacheDir: cacheDir})
+	assert.Nil(t, err)
+	assert.Len(t, results[0].Violations, 1)
+	assert.Equal(t, "/spec: additional properties 'replica' not allowed", results[0].Violations[0].Message)
+}
@@ -0,0 +1,58 @@
+package safeguards
+
+import (
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// suppressViolations splits the violations into the ones that are reported and the ones the violating object suppresses
+// through the safeguards-ignore annotation
+func suppressViolations(objects []*unstructured.Unstructured, violations []types.Violation) ([]types.Violation, []types.Violation) {
+	type suppression struct {
+		safeguards []string
+		reason     string
+	}
+
+	suppressions := make(map[string]suppression)
+	for _, o := range objects {
+		annotations := o.GetAnnotations()
+		ignored, ok := annotations[types.SuppressionAnnotation]
+		if !ok {
+			continue
+		}
+
+		reason := strings.TrimSpace(annotations[types.SuppressionReasonAnnotation])
+		if reason == "" {
+			log.Warnf("ignoring %s annotation on %s %s: the %s annotation is required", types.SuppressionAnnotation, o.GetKind(), o.GetName(), types.SuppressionReasonAnnotation)
+			continue
+		}
+
+		var safeguards []string
+		for _, name := range strings.Split(ignored, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				safeguards = append(safeguards, name)
+			}
+		}
+		suppressions[types.ObjectKey(o.GetAPIVersion(), o.GetKind(), o.GetNamespace(), o.GetName())] = suppression{safeguards: safeguards, reason: reason}
+	}
+
+	reported := make([]types.Violation, 0, len(violations))
+	var suppressed []types.Violation
+	for _, v := range violations {
+		s, ok := suppressions[v.ObjectKey()]
+		if !ok || !(slices.Contains(s.safeguards, v.Constraint) || slices.Contains(s.safeguards, types.Constraint_all)) {
+			reported = append(reported, v)
+			continue
+		}
+
+		v.Reason = s.reason
+		suppressed = append(suppressed, v)
+	}
+
+	return reported, suppressed
+}
@@ -0,0 +1,40 @@
+package safeguards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+const suppressedManifestPath = "tests/suppressions/suppressed-manifest.yaml"
+
+func TestSuppressViolations(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(suppressedManifestPath, opt)
+	assert.Nil(t, err)
+	results, err := GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+
+	// the annotated constraints are suppressed with their reason
+	assert.NotEmpty(t, results[0].Suppressed)
+	for _, v := range results[0].Suppressed {
+		assert.Equal(t, "suppressed-deployment", v.Name)
+		assert.Contains(t, []string{types.Constraint_CEP, types.Constraint_CRL}, v.Constraint)
+		assert.Equal(t, "batch worker without an http endpoint", v.Reason)
+	}
+
+	// suppressions without a reason are not honored
+	var reportedObjects []string
+	for _, v := range results[0].Violations {
+		reportedObjects = append(reportedObjects, v.Name)
+		if v.Name == "suppressed-deployment" {
+			assert.NotContains(t, []string{types.Constraint_CEP, types.Constraint_CRL}, v.Constraint)
+		}
+	}
+	assert.Contains(t, reportedObjects, "unreasoned-deployment")
+	assert.Contains(t, results[0].ObjectViolations, types.ObjectKey("apps/v1", "Deployment", "", "unreasoned-deployment"))
+}
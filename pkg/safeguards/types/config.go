@@ -0,0 +1,27 @@
+package types
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+
+	// DefaultConfigPath is where a repository keeps its safeguards configuration
+	DefaultConfigPath = ".draft/safeguards.yaml"
+
+	// SuppressionAnnotation lists the comma separated safeguards, or "all", whose violations are accepted for an object
+	SuppressionAnnotation = "draft.azure.com/safeguards-ignore"
+	// SuppressionReasonAnnotation explains why the violations are accepted, suppressions without a reason are not honored
+	SuppressionReasonAnnotation = "draft.azure.com/safeguards-ignore-reason"
+)
+
+// SafeguardsConfig customizes which safeguards are evaluated and how their violations are reported
+type SafeguardsConfig struct {
+	Version     string                      `json:"version,omitempty"`     // the safeguards library version to validate against, pinned so upgrading draft does not change results
+	Exclude     []string                    `json:"exclude,omitempty"`     // names of the safeguards to skip
+	Constraints map[string]ConstraintConfig `json:"constraints,omitempty"` // per-safeguard settings keyed by safeguard name
+}
+
+// ConstraintConfig holds the settings of a single safeguard
+type ConstraintConfig struct {
+	Severity   string                 `json:"severity,omitempty"`   // error (default) or warning, warnings do not fail validation
+	Parameters map[string]interface{} `json:"parameters,omitempty"` // overrides merged into the constraint's spec.parameters
+}
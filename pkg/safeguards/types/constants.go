@@ -1,9 +1,5 @@
 package types
 
-import (
-	"fmt"
-)
-
 const (
 	Constraint_CAI   = "container-allowed-images"
 	Constraint_CEP   = "container-enforce-probes"
@@ -15,7 +11,14 @@ const (
 	Constraint_USS   = "unique-service-selectors"
 	Constraint_all   = "all"
 
+	// Constraint_Schema names the violations raised by Kubernetes schema validation rather than a safeguard
+	Constraint_Schema = "kubernetes-schema"
+	// Constraint_DeprecatedAPI names the violations raised for deprecated and removed API versions
+	Constraint_DeprecatedAPI = "deprecated-api-versions"
+
 	KustomizationPath       = "../tests/kustomize/overlays/production"
+	KustomizeRoot           = "../tests/kustomize"
+	KustomizeSharedRoot     = "../tests/kustomize-shared"
 	DirectPath_ToValidChart = "../tests/testmanifests/validchart/Chart.yaml"
 	ChartPath               = "../tests/testmanifests/validchart"
 	InvalidChartPath        = "../tests/testmanifests/invalidchart"
@@ -25,6 +28,9 @@ const (
 	FolderwithHelpersTmpl   = "../tests/testmanifests/different-structure"
 	MultipleTemplateDirs    = "../tests/testmanifests/multiple-templates"
 	MultipleValuesFile      = "../tests/testmanifests/multiple-values-files"
+	OverrideValuesFile      = "../tests/testmanifests/overrides/production.yaml"
+	RemoteDependencies      = "../tests/testmanifests/remote-dependencies"
+	RemoteDependencySource  = "../tests/testmanifests/remote-dependencies-src/remotechart"
 
 	Subcharts                  = "../tests/testmanifests/multiple-charts"
 	SubchartDir                = "../tests/testmanifests/multiple-charts/charts/subchart2"
@@ -36,52 +42,28 @@ const (
 	ConstraintFileName = "constraint.yaml"
 )
 
+// SelectedVersion was the safeguards version the package evaluated, it is no longer read.
+//
+// Deprecated: select the version with the Version of safeguards.ValidatorOptions.
 var SelectedVersion = "v1.0.0"
 
+// SupportedVersions listed the safeguards versions, it is no longer read.
+//
+// Deprecated: safeguards.SupportedVersions lists the versions of the embedded library.
 var SupportedVersions = []string{SelectedVersion}
 
-var Safeguard_CRIP = Safeguard{
-	Name:           Constraint_CRIP,
-	TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CRIP, TemplateFileName),
-	ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CRIP, ConstraintFileName),
-}
+// Safeguard_CRIP and Safeguards name the built-in safeguards, the safeguards package resolves their template and
+// constraint paths within the selected version of its embedded library
+var Safeguard_CRIP = Safeguard{Name: Constraint_CRIP}
 
 var Safeguards = []Safeguard{
-	{
-		Name:           Constraint_CAI,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CAI, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CAI, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_CEP,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CEP, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CEP, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_CRL,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CRL, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_CRL, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_DBPDB,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_DBPDB, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_DBPDB, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_PEA,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_PEA, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_PEA, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_RT,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_RT, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_RT, ConstraintFileName),
-	},
-	{
-		Name:           Constraint_USS,
-		TemplatePath:   fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_USS, TemplateFileName),
-		ConstraintPath: fmt.Sprintf("lib/%s/%s/%s", SelectedVersion, Constraint_USS, ConstraintFileName),
-	},
+	{Name: Constraint_CAI},
+	{Name: Constraint_CEP},
+	{Name: Constraint_CRL},
+	{Name: Constraint_DBPDB},
+	{Name: Constraint_PEA},
+	{Name: Constraint_RT},
+	{Name: Constraint_USS},
 }
 
 var SafeguardsTesting = append(Safeguards, Safeguard_CRIP)
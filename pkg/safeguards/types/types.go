@@ -28,12 +28,65 @@ type Safeguard struct {
 type ManifestFile struct {
 	Name            string
 	ManifestContent []byte
+	Source          string // the path the manifest was read from, its template path within a rendered Helm chart, or its kustomization path and resource id
+	Rendered        bool   // whether the manifest was rendered from a Helm chart or kustomization rather than read from Source
 }
 
 type ManifestResult struct {
-	Name             string              // the name of the manifest
-	ObjectViolations map[string][]string // a map of string object names to slice of string objectViolations
-	ViolationsCount  int                 // a count of how many violations are associated with this manifest
+	Name             string              `json:"name"`             // the name of the manifest
+	Source           string              `json:"source,omitempty"` // the full path or Helm template path of the manifest, if known
+	ObjectViolations map[string][]string `json:"objectViolations"` // violation messages keyed by the ObjectKey of the violating object
+	ViolationsCount  int                 `json:"violationsCount"`  // the number of violations in the manifest, counting every violation of an object
+	Violations       []Violation         `json:"violations"`       // every violation found in the manifest, in review order
+	Suppressed       []Violation         `json:"suppressed"`       // violations suppressed by an annotation on their object
+}
+
+// AddViolations adds violations found by another check, like schema validation, to the result
+func (r *ManifestResult) AddViolations(violations ...Violation) {
+	if r.ObjectViolations == nil {
+		r.ObjectViolations = make(map[string][]string)
+	}
+	for _, v := range violations {
+		r.Violations = append(r.Violations, v)
+		r.ObjectViolations[v.ObjectKey()] = append(r.ObjectViolations[v.ObjectKey()], v.Message)
+	}
+	r.ViolationsCount = len(r.Violations)
+}
+
+// Merge adds the violations and suppressed violations another check found in the same manifest to the result
+func (r *ManifestResult) Merge(other ManifestResult) {
+	r.AddViolations(other.Violations...)
+	r.Suppressed = append(r.Suppressed, other.Suppressed...)
+}
+
+// Violation describes a single constraint violation raised against an object within a manifest
+type Violation struct {
+	Constraint     string `json:"constraint"`          // the name of the violated safeguard
+	ConstraintName string `json:"constraintName"`      // the metadata.name of the violated constraint
+	Severity       string `json:"severity"`            // the configured severity of the safeguard, error or warning
+	APIVersion     string `json:"apiVersion"`          // the group/version of the violating object
+	Kind           string `json:"kind"`                // the kind of the violating object
+	Namespace      string `json:"namespace,omitempty"` // the namespace of the violating object, if set
+	Name           string `json:"name"`                // the name of the violating object
+	Message        string `json:"message"`             // the violation message returned by the constraint
+	File           string `json:"file"`                // the full path or Helm template path of the manifest the object was read from
+	Document       int    `json:"document"`            // the index of the object's document within the multi-document manifest
+	Line           int    `json:"line,omitempty"`      // the line the object starts on within the manifest, 0 when unknown
+	Reason         string `json:"reason,omitempty"`    // the reason given for suppressing the violation, if suppressed
+}
+
+// ObjectKey identifies the violating object by its group/version, kind, namespace and name
+func (v Violation) ObjectKey() string {
+	return ObjectKey(v.APIVersion, v.Kind, v.Namespace, v.Name)
+}
+
+// ObjectKey identifies an object by its group/version, kind, namespace and name, e.g. "apps/v1 Deployment default/myapp",
+// so objects of different kinds sharing a name, like draft's Deployment and Service, are told apart
+func ObjectKey(apiVersion, kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s %s %s", apiVersion, kind, name)
+	}
+	return fmt.Sprintf("%s %s %s/%s", apiVersion, kind, namespace, name)
 }
 
 // methods for retrieval of manifest, constraint templates, and constraints
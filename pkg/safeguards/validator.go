@@ -0,0 +1,179 @@
+package safeguards
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	constraintclient "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+// ValidatorOptions configures the safeguards a Validator evaluates
+type ValidatorOptions struct {
+	Version    string                 // the safeguards version, e.g. v1.0.0, defaults to the version pinned in Config or else the latest supported version
+	Safeguards []string               // the built-in safeguards to enable, defaults to every safeguard of the version except container-restricted-image-pulls
+	PolicyDirs []string               // local directories of extra policies, read like PolicyFS
+	PolicyFS   []fs.FS                // extra policies, one per directory holding a template.yaml and constraint.yaml
+	Config     types.SafeguardsConfig // the exclusions, parameter overrides and severities applied to the enabled safeguards
+}
+
+// Validator validates manifests against the safeguards it was created with. It owns its constraint client and does not
+// depend on package state, so validators with different options can coexist and a validator can be shared by goroutines.
+type Validator struct {
+	mu             sync.Mutex // serializes reviews, which load the manifest objects into the client
+	client         *constraintclient.Client
+	safeguardNames map[string]string // safeguard names keyed by the name of their constraint
+	config         types.SafeguardsConfig
+}
+
+// NewValidator creates a constraint client and loads the templates and constraints of every safeguard enabled by the
+// options into it
+func NewValidator(ctx context.Context, opts ValidatorOptions) (*Validator, error) {
+	version, err := selectVersion(opts.Version, opts.Config.Version)
+	if err != nil {
+		return nil, err
+	}
+	names := opts.Safeguards
+	if len(names) == 0 {
+		for _, sg := range types.Safeguards {
+			if isVersionSafeguard(version, sg.Name) {
+				names = append(names, sg.Name)
+			}
+		}
+	}
+	builtins, err := builtinSafeguards(version, names)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("validating against safeguards %s", version)
+
+	crawlers := []types.FileCrawler{{Safeguards: builtins, ConstraintFS: embedFS}}
+	known := slices.Clone(names)
+	addPolicies := func(policyFS fs.FS, source string) error {
+		policies, err := readPolicyFS(policyFS)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", source, err)
+		}
+		for _, sg := range policies {
+			if slices.Contains(known, sg.Name) {
+				return fmt.Errorf("policy %s in %s duplicates an existing safeguard", sg.Name, source)
+			}
+			known = append(known, sg.Name)
+		}
+		log.Debugf("adding %d policies from %s", len(policies), source)
+		crawlers = append(crawlers, types.FileCrawler{Safeguards: policies, ConstraintFS: policyFS})
+		return nil
+	}
+	for _, dir := range opts.PolicyDirs {
+		if err = addPolicies(os.DirFS(dir), "policy directory "+dir); err != nil {
+			return nil, err
+		}
+	}
+	for i, policyFS := range opts.PolicyFS {
+		if err = addPolicies(policyFS, fmt.Sprintf("policy file system %d", i)); err != nil {
+			return nil, err
+		}
+	}
+
+	// the requested version overrides the pinned one
+	config := opts.Config
+	config.Version = version
+	// safeguards that are not enabled, like container-restricted-image-pulls, can still be configured
+	isKnown := func(name string) bool { return isBuiltinSafeguard(name) || slices.Contains(known, name) }
+	if err = validateConfig(config, isKnown); err != nil {
+		return nil, err
+	}
+
+	return newValidator(ctx, crawlers, config)
+}
+
+// newValidator creates a validator for the safeguards of the crawlers, applying the configuration to them
+func newValidator(ctx context.Context, crawlers []types.FileCrawler, config types.SafeguardsConfig) (*Validator, error) {
+	// constraint client instantiation
+	c, err := getConstraintClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// retrieval of templates, constraints, and deployment for every safeguard that is not excluded
+	constraintTemplates, constraints, safeguardNames, err := readPolicies(crawlers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// loading of templates, constraints into constraint client
+	err = loadConstraintTemplates(ctx, c, constraintTemplates)
+	if err != nil {
+		return nil, err
+	}
+	err = loadConstraints(ctx, c, constraints)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{client: c, safeguardNames: safeguardNames, config: config}, nil
+}
+
+// selectVersion returns the requested safeguards version, else the pinned one, else the latest, failing for versions
+// the embedded library does not have
+func selectVersion(requested, pinned string) (string, error) {
+	version := requested
+	if version == "" {
+		version = pinned
+	}
+	if version == "" {
+		return getLatestSafeguardsVersion()
+	}
+
+	versions, err := SupportedVersions()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(versions, version) {
+		return "", fmt.Errorf("unsupported safeguards version %q, must be one of: %s", version, strings.Join(versions, ", "))
+	}
+	return version, nil
+}
+
+// builtinSafeguards returns the named built-in safeguards of a supported safeguards version
+func builtinSafeguards(version string, names []string) ([]types.Safeguard, error) {
+	safeguards := make([]types.Safeguard, 0, len(names))
+	for _, name := range names {
+		if !isBuiltinSafeguard(name) {
+			return nil, fmt.Errorf("unknown safeguard %q", name)
+		}
+		if !isVersionSafeguard(version, name) {
+			return nil, fmt.Errorf("safeguard %s is not part of safeguards %s", name, version)
+		}
+		if slices.ContainsFunc(safeguards, func(sg types.Safeguard) bool { return sg.Name == name }) {
+			continue
+		}
+		safeguards = append(safeguards, types.Safeguard{
+			Name:           name,
+			TemplatePath:   path.Join("lib", version, name, types.TemplateFileName),
+			ConstraintPath: path.Join("lib", version, name, types.ConstraintFileName),
+		})
+	}
+	return safeguards, nil
+}
+
+// isVersionSafeguard reports whether a version of the embedded library has the named safeguard
+func isVersionSafeguard(version, name string) bool {
+	_, err := fs.Stat(embedFS, path.Join("lib", version, name, types.TemplateFileName))
+	return err == nil
+}
+
+// isBuiltinSafeguard reports whether name is a safeguard of the embedded library
+func isBuiltinSafeguard(name string) bool {
+	return name == types.Safeguard_CRIP.Name || slices.ContainsFunc(types.Safeguards, func(sg types.Safeguard) bool {
+		return sg.Name == name
+	})
+}
@@ -0,0 +1,172 @@
+package safeguards
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Azure/draft/pkg/safeguards/types"
+)
+
+func TestNewValidator(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+
+	v, err := NewValidator(ctx, ValidatorOptions{})
+	assert.Nil(t, err)
+	results, err := v.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, violatedSafeguards(results), types.Constraint_CEP)
+	assert.NotContains(t, violatedSafeguards(results), types.Constraint_CRIP)
+
+	// only the enabled safeguards are evaluated, with the configured severity
+	v, err = NewValidator(ctx, ValidatorOptions{
+		Version:    "v1.0.0",
+		Safeguards: []string{types.Constraint_CEP, types.Constraint_CRIP},
+		Config: types.SafeguardsConfig{Constraints: map[string]types.ConstraintConfig{
+			types.Constraint_CEP: {Severity: types.SeverityWarning},
+		}},
+	})
+	assert.Nil(t, err)
+	results, err = v.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	for _, r := range results {
+		for _, violation := range r.Violations {
+			assert.Contains(t, []string{types.Constraint_CEP, types.Constraint_CRIP}, violation.Constraint)
+			if violation.Constraint == types.Constraint_CEP {
+				assert.Equal(t, types.SeverityWarning, violation.Severity)
+			}
+		}
+	}
+	assert.Contains(t, violatedSafeguards(results), types.Constraint_CEP)
+
+	// safeguards that are not enabled can be configured
+	v, err = NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{Constraints: map[string]types.ConstraintConfig{
+		types.Constraint_CRIP: {Severity: types.SeverityWarning},
+	}}})
+	assert.Nil(t, err)
+	results, err = v.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.NotContains(t, violatedSafeguards(results), types.Constraint_CRIP)
+}
+
+func TestNewValidatorInvalidOptions(t *testing.T) {
+	_, err := NewValidator(ctx, ValidatorOptions{Version: "v0.1.0"})
+	assert.ErrorContains(t, err, "unsupported safeguards version")
+	_, err = NewValidator(ctx, ValidatorOptions{Safeguards: []string{"not-a-safeguard"}})
+	assert.ErrorContains(t, err, "unknown safeguard")
+	_, err = NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{Exclude: []string{"not-a-safeguard"}}})
+	assert.NotNil(t, err)
+	_, err = NewValidator(ctx, ValidatorOptions{PolicyFS: []fs.FS{os.DirFS("tests/all")}})
+	assert.ErrorContains(t, err, "no policies found")
+	_, err = NewValidator(ctx, ValidatorOptions{PolicyFS: []fs.FS{os.DirFS(duplicatePolicyDir)}})
+	assert.ErrorContains(t, err, "duplicates an existing safeguard")
+}
+
+func TestNewValidatorPolicyFS(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+
+	v, err := NewValidator(ctx, ValidatorOptions{PolicyFS: []fs.FS{os.DirFS(policyDir)}})
+	assert.Nil(t, err)
+	results, err := v.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Contains(t, violatedSafeguards(results), "required-owner-label")
+
+	// policies of a validator are not visible to other validators
+	other, err := NewValidator(ctx, ValidatorOptions{})
+	assert.Nil(t, err)
+	results, err = other.GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.NotContains(t, violatedSafeguards(results), "required-owner-label")
+}
+
+func TestValidatorConcurrentUse(t *testing.T) {
+	var opt chartutil.ReleaseOptions
+	errorFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+	successFiles, err := GetManifestFiles("tests/all/success/all-success-manifest-1.yaml", opt)
+	assert.Nil(t, err)
+
+	v, err := NewValidator(ctx, ValidatorOptions{})
+	assert.Nil(t, err)
+	expectedErrors, err := v.GetManifestResults(ctx, errorFiles)
+	assert.Nil(t, err)
+	expectedSuccess, err := v.GetManifestResults(ctx, successFiles)
+	assert.Nil(t, err)
+
+	// objects reviewed concurrently must not leak into each other's results
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			results, err := v.GetManifestResults(ctx, errorFiles)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedErrors, results)
+		}()
+		go func() {
+			defer wg.Done()
+			results, err := v.GetManifestResults(ctx, successFiles)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedSuccess, results)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAddSafeguardCRIPOnce(t *testing.T) {
+	defer cripAdded.Store(false)
+
+	AddSafeguardCRIP()
+	AddSafeguardCRIP()
+
+	// the constraint is loaded once, a duplicate would fail to load
+	var opt chartutil.ReleaseOptions
+	manifestFiles, err := GetManifestFiles(allErrorManifestPath, opt)
+	assert.Nil(t, err)
+	_, err = GetManifestResults(ctx, manifestFiles)
+	assert.Nil(t, err)
+	assert.Len(t, types.Safeguards, 7)
+}
+
+func TestSupportedVersions(t *testing.T) {
+	versions, err := SupportedVersions()
+	assert.Nil(t, err)
+	assert.Contains(t, versions, "v1.0.0")
+	// test manifests under lib/manifests are not a safeguards version
+	assert.NotContains(t, versions, "manifests")
+
+	latest, err := getLatestSafeguardsVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, versions[len(versions)-1], latest)
+}
+
+func TestSelectVersion(t *testing.T) {
+	latest, err := getLatestSafeguardsVersion()
+	assert.Nil(t, err)
+
+	version, err := selectVersion("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, latest, version)
+
+	version, err = selectVersion("", "v1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.0.0", version)
+
+	// the requested version overrides the pinned one
+	version, err = selectVersion("v1.0.0", "v9.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.0.0", version)
+
+	_, err = selectVersion("", "v9.0.0")
+	assert.ErrorContains(t, err, "unsupported safeguards version")
+	_, err = NewValidator(ctx, ValidatorOptions{Config: types.SafeguardsConfig{Version: "v9.0.0"}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,287 @@
+package templatepacks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/Azure/draft/pkg/handlers"
+)
+
+// indexFileName is the file in the store directory that records the installed packs
+const indexFileName = "packs.yaml"
+
+var packNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
+
+// Pack is a template pack installed in a store. A pack has the directory layout of draft's template directory, every
+// draft.yaml in it defining a template.
+type Pack struct {
+	Name      string   `json:"name"`
+	Source    string   `json:"source"`
+	Checksum  string   `json:"checksum"`        // the h1: directory hash of the pack, like the hashes of go.sum
+	Templates []string `json:"templates"`       // the names of the templates the pack provides
+	Stamp     string   `json:"stamp,omitempty"` // the names, sizes and modification times of the files the checksum was last verified for
+}
+
+// AddOptions configures how a pack is added to a store
+type AddOptions struct {
+	Name     string // the name of the pack, defaults to the last element of the source
+	Checksum string // the expected h1: checksum of the pack, not verified when empty
+	Force    bool   // replace an installed pack with the same name
+}
+
+// Store manages the template packs installed in a directory
+type Store struct {
+	Dir string
+}
+
+// DefaultStoreDir returns the directory template packs are installed in, ~/.draft/templates
+func DefaultStoreDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".draft", "templates"), nil
+}
+
+// NewStore returns a store for the packs installed in dir, which is created when the first pack is added
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Add fetches the pack at source into the store. Sources starting with oci:// are OCI artifacts in a registry, sources
+// starting with oci-layout:// are OCI artifacts in a local OCI image layout directory and any other source is a git
+// repository URL. A #<branch or tag> suffix selects the git revision. The pack must hold valid templates that no other
+// installed pack provides and match opts.Checksum when given.
+func (s *Store) Add(ctx context.Context, source string, opts AddOptions) (*Pack, error) {
+	src, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	name := opts.Name
+	if name == "" {
+		name = src.defaultName()
+	}
+	if !packNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid pack name %q, must consist of lower case alphanumeric characters, '-' or '_'", name)
+	}
+
+	packs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(packs, func(p Pack) bool { return p.Name == name }) && !opts.Force {
+		return nil, fmt.Errorf("template pack %s is already installed, remove it first or add it with --force", name)
+	}
+
+	if err = os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating template pack store: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp(s.Dir, ".fetch-")
+	if err != nil {
+		return nil, fmt.Errorf("creating template pack download directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	fetchDir := filepath.Join(tmpDir, name)
+
+	log.Debugf("fetching template pack %s from %s", name, source)
+	if err = src.fetch(ctx, fetchDir); err != nil {
+		return nil, fmt.Errorf("fetching template pack from %s: %w", source, err)
+	}
+	if err = checkPackFiles(fetchDir); err != nil {
+		return nil, err
+	}
+
+	checksum, err := hashPack(fetchDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Checksum != "" && opts.Checksum != checksum {
+		return nil, fmt.Errorf("checksum mismatch for template pack %s: expected %s, got %s", source, opts.Checksum, checksum)
+	}
+
+	templates, err := handlers.ReadTemplateDir(fetchDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no templates found in %s", source)
+	}
+	for _, p := range packs {
+		if p.Name == name {
+			continue
+		}
+		for _, t := range templates {
+			if slices.Contains(p.Templates, t) {
+				return nil, fmt.Errorf("template %s is already provided by template pack %s", t, p.Name)
+			}
+		}
+	}
+
+	// swap the pack in only once it is verified, keeping an installed pack of the same name on failure
+	packDir := filepath.Join(s.Dir, name)
+	if err = os.RemoveAll(packDir); err != nil {
+		return nil, fmt.Errorf("removing template pack %s: %w", name, err)
+	}
+	if err = os.Rename(fetchDir, packDir); err != nil {
+		return nil, fmt.Errorf("installing template pack %s: %w", name, err)
+	}
+
+	stamp, err := stampPack(packDir)
+	if err != nil {
+		return nil, err
+	}
+	pack := Pack{Name: name, Source: source, Checksum: checksum, Templates: templates, Stamp: stamp}
+	packs = slices.DeleteFunc(packs, func(p Pack) bool { return p.Name == name })
+	packs = append(packs, pack)
+	if err = s.writeIndex(packs); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// List returns the installed packs sorted by name
+func (s *Store) List() ([]Pack, error) {
+	content, err := os.ReadFile(filepath.Join(s.Dir, indexFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Pack{}, nil
+		}
+		return nil, fmt.Errorf("reading template pack index: %w", err)
+	}
+	var packs []Pack
+	if err = yaml.Unmarshal(content, &packs); err != nil {
+		return nil, fmt.Errorf("parsing template pack index: %w", err)
+	}
+	slices.SortFunc(packs, func(a, b Pack) int { return strings.Compare(a.Name, b.Name) })
+	return packs, nil
+}
+
+// Remove deletes an installed pack
+func (s *Store) Remove(name string) error {
+	packs, err := s.List()
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(packs, func(p Pack) bool { return p.Name == name }) {
+		return fmt.Errorf("template pack %s is not installed", name)
+	}
+	if err = os.RemoveAll(filepath.Join(s.Dir, name)); err != nil {
+		return fmt.Errorf("removing template pack %s: %w", name, err)
+	}
+	return s.writeIndex(slices.DeleteFunc(packs, func(p Pack) bool { return p.Name == name }))
+}
+
+// Dirs returns the directories of the installed packs whose content still matches their checksum. Modified packs are
+// skipped with a warning. Only packs whose files changed since their checksum was last verified are hashed again.
+func (s *Store) Dirs() ([]string, error) {
+	packs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(packs))
+	restamped := false
+	for i, p := range packs {
+		stamp, err := stampPack(filepath.Join(s.Dir, p.Name))
+		if err != nil || stamp != p.Stamp {
+			if err = s.Verify(p); err != nil {
+				log.Warnf("skipping template pack %s: %s", p.Name, err.Error())
+				continue
+			}
+			packs[i].Stamp = stamp
+			restamped = true
+		}
+		dirs = append(dirs, filepath.Join(s.Dir, p.Name))
+	}
+	if restamped {
+		// the packs are verified again next time when the index cannot be updated
+		if err = s.writeIndex(packs); err != nil {
+			log.Debugf("not recording verified template packs: %s", err.Error())
+		}
+	}
+	return dirs, nil
+}
+
+// Verify checks that the installed content of a pack matches its checksum
+func (s *Store) Verify(p Pack) error {
+	checksum, err := hashPack(filepath.Join(s.Dir, p.Name))
+	if err != nil {
+		return err
+	}
+	if checksum != p.Checksum {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s, add the pack again to restore it", p.Checksum, checksum)
+	}
+	return nil
+}
+
+func (s *Store) writeIndex(packs []Pack) error {
+	content, err := yaml.Marshal(packs)
+	if err != nil {
+		return fmt.Errorf("marshalling template pack index: %w", err)
+	}
+	// write then rename so an interrupted write does not lose the index
+	tmpFile := filepath.Join(s.Dir, "."+indexFileName)
+	if err = os.WriteFile(tmpFile, content, 0644); err != nil {
+		return fmt.Errorf("writing template pack index: %w", err)
+	}
+	if err = os.Rename(tmpFile, filepath.Join(s.Dir, indexFileName)); err != nil {
+		return fmt.Errorf("writing template pack index: %w", err)
+	}
+	return nil
+}
+
+// hashPack returns the h1: checksum of the files of a pack directory
+func hashPack(dir string) (string, error) {
+	checksum, err := dirhash.HashDir(dir, "", dirhash.Hash1)
+	if err != nil {
+		return "", fmt.Errorf("computing checksum of %s: %w", dir, err)
+	}
+	return checksum, nil
+}
+
+// stampPack returns a digest of the names, sizes and modification times of the files of a pack directory, which changes
+// whenever a file of the pack is modified without reading the files like hashPack does
+func stampPack(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(h, "%s %d %d\n", filepath.ToSlash(rel), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading files of %s: %w", dir, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkPackFiles rejects packs holding anything but directories and regular files, like symlinks pointing outside them
+func checkPackFiles(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			rel, _ := filepath.Rel(dir, path)
+			return fmt.Errorf("template pack file %s is not a regular file", rel)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,246 @@
+package templatepacks
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/Azure/draft/pkg/handlers"
+)
+
+var ctx = context.Background()
+
+// writePack writes a pack with a dockerfile template for each language to dir
+func writePack(t *testing.T, dir string, languages ...string) {
+	for _, language := range languages {
+		langDir := filepath.Join(dir, "dockerfiles", language)
+		assert.Nil(t, os.MkdirAll(langDir, 0755))
+		draftYaml := fmt.Sprintf("templateName: \"dockerfile-%s\"\ntype: \"dockerfile\"\nversions: [\"0.0.1\"]\ndefaultVersion: \"0.0.1\"\n", language)
+		assert.Nil(t, os.WriteFile(filepath.Join(langDir, "draft.yaml"), []byte(draftYaml), 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(langDir, "Dockerfile"), []byte("FROM "+language+"\n"), 0644))
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=draft", "-c", "user.email=draft@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(out))
+}
+
+// newGitPack creates a bare git repository holding a pack, returning its path
+func newGitPack(t *testing.T, languages ...string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	work := t.TempDir()
+	writePack(t, work, languages...)
+	git(t, work, "init", "-q")
+	git(t, work, "add", "-A")
+	git(t, work, "commit", "-q", "-m", "templates")
+	git(t, work, "tag", "v1")
+
+	bare := filepath.Join(t.TempDir(), "golden-templates.git")
+	git(t, work, "clone", "-q", "--bare", work, bare)
+	return bare
+}
+
+// newOCILayoutPack creates an OCI layout holding a pack artifact tagged v1, returning the layout path
+func newOCILayoutPack(t *testing.T, layerMediaType string, languages ...string) string {
+	packDir := t.TempDir()
+	writePack(t, packDir, languages...)
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	assert.Nil(t, tw.AddFS(os.DirFS(packDir)))
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+
+	layout := t.TempDir()
+	store, err := oci.New(layout)
+	assert.Nil(t, err)
+	layer, err := oras.PushBytes(ctx, store, layerMediaType, archive.Bytes())
+	assert.Nil(t, err)
+	manifest, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.azure.draft.templates", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layer},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Tag(ctx, manifest, "v1"))
+	return layout
+}
+
+func TestAddGitPack(t *testing.T) {
+	repo := newGitPack(t, "golden")
+	store := NewStore(filepath.Join(t.TempDir(), "templates"))
+
+	pack, err := store.Add(ctx, repo+"#v1", AddOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "golden-templates", pack.Name)
+	assert.Equal(t, []string{"dockerfile-golden"}, pack.Templates)
+	assert.Regexp(t, "^h1:", pack.Checksum)
+	assert.FileExists(t, filepath.Join(store.Dir, pack.Name, "dockerfiles", "golden", "Dockerfile"))
+	assert.NoDirExists(t, filepath.Join(store.Dir, pack.Name, ".git"))
+
+	packs, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []Pack{*pack}, packs)
+
+	// the installed pack registers its templates
+	dirs, err := store.Dirs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(store.Dir, pack.Name)}, dirs)
+	t.Cleanup(func() { assert.Nil(t, handlers.LoadTemplateDirs()) })
+	assert.Nil(t, handlers.LoadTemplateDirs(dirs...))
+	assert.True(t, handlers.IsValidTemplate("dockerfile-golden"))
+
+	_, err = store.Add(ctx, repo, AddOptions{})
+	assert.ErrorContains(t, err, "already installed")
+	_, err = store.Add(ctx, repo, AddOptions{Force: true, Checksum: pack.Checksum})
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Remove(pack.Name))
+	assert.NoDirExists(t, filepath.Join(store.Dir, pack.Name))
+	packs, err = store.List()
+	assert.Nil(t, err)
+	assert.Empty(t, packs)
+	assert.ErrorContains(t, store.Remove(pack.Name), "not installed")
+}
+
+func TestAddOCILayoutPack(t *testing.T) {
+	layout := newOCILayoutPack(t, PackMediaType, "golden")
+	store := NewStore(t.TempDir())
+
+	pack, err := store.Add(ctx, "oci-layout://"+layout+":v1", AddOptions{Name: "golden"})
+	assert.Nil(t, err)
+	assert.Equal(t, "golden", pack.Name)
+	assert.Equal(t, []string{"dockerfile-golden"}, pack.Templates)
+	content, err := os.ReadFile(filepath.Join(store.Dir, "golden", "dockerfiles", "golden", "Dockerfile"))
+	assert.Nil(t, err)
+	assert.Equal(t, "FROM golden\n", string(content))
+
+	_, err = store.Add(ctx, "oci-layout://"+layout+":v2", AddOptions{Name: "other"})
+	assert.NotNil(t, err)
+	_, err = store.Add(ctx, "oci-layout://"+newOCILayoutPack(t, ocispec.MediaTypeImageLayerGzip, "other")+":v1", AddOptions{})
+	assert.ErrorContains(t, err, "has no "+PackMediaType+" layer")
+}
+
+func TestAddPackChecksum(t *testing.T) {
+	repo := newGitPack(t, "golden")
+	store := NewStore(t.TempDir())
+
+	_, err := store.Add(ctx, repo, AddOptions{Checksum: "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="})
+	assert.ErrorContains(t, err, "checksum mismatch")
+	packs, err := store.List()
+	assert.Nil(t, err)
+	assert.Empty(t, packs)
+
+	// a pack modified after it was added is not registered
+	pack, err := store.Add(ctx, repo, AddOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, store.Verify(*pack))
+	assert.Nil(t, os.WriteFile(filepath.Join(store.Dir, pack.Name, "dockerfiles", "golden", "Dockerfile"), []byte("FROM evil\n"), 0644))
+	assert.ErrorContains(t, store.Verify(*pack), "checksum mismatch")
+	dirs, err := store.Dirs()
+	assert.Nil(t, err)
+	assert.Empty(t, dirs)
+}
+
+func TestDirsRecordsVerifiedPacks(t *testing.T) {
+	store := NewStore(t.TempDir())
+	pack, err := store.Add(ctx, newGitPack(t, "golden"), AddOptions{})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, pack.Stamp)
+
+	// packs of an index without stamps are hashed once, then recorded as verified
+	unstamped := *pack
+	unstamped.Stamp = ""
+	assert.Nil(t, store.writeIndex([]Pack{unstamped}))
+	dirs, err := store.Dirs()
+	assert.Nil(t, err)
+	assert.Len(t, dirs, 1)
+	packs, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, pack.Stamp, packs[0].Stamp)
+}
+
+func TestAddInvalidPack(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	_, err := store.Add(ctx, filepath.Join(t.TempDir(), "missing.git"), AddOptions{})
+	assert.ErrorContains(t, err, "git clone")
+	_, err = store.Add(ctx, newGitPack(t, "golden"), AddOptions{Name: "Not A Name"})
+	assert.ErrorContains(t, err, "invalid pack name")
+	_, err = store.Add(ctx, "oci://example.com/templates", AddOptions{})
+	assert.ErrorContains(t, err, "no tag or digest")
+
+	// two packs cannot provide the same template
+	_, err = store.Add(ctx, newGitPack(t, "golden"), AddOptions{Name: "first"})
+	assert.Nil(t, err)
+	_, err = store.Add(ctx, newGitPack(t, "golden"), AddOptions{Name: "second"})
+	assert.ErrorContains(t, err, "already provided by template pack first")
+
+	entries, err := os.ReadDir(store.Dir)
+	assert.Nil(t, err)
+	for _, e := range entries {
+		assert.NotRegexp(t, `^\.fetch-`, e.Name(), "download directories are cleaned up")
+	}
+}
+
+func TestExtractTarGzRejectsEscapingEntries(t *testing.T) {
+	for _, header := range []*tar.Header{
+		{Name: "../outside", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "/absolute", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		var archive bytes.Buffer
+		gz := gzip.NewWriter(&archive)
+		tw := tar.NewWriter(gz)
+		assert.Nil(t, tw.WriteHeader(header))
+		assert.Nil(t, tw.Close())
+		assert.Nil(t, gz.Close())
+
+		dir := t.TempDir()
+		assert.NotNil(t, extractTarGz(&archive, dir), header.Name)
+		files, err := fs.Glob(os.DirFS(dir), "*")
+		assert.Nil(t, err)
+		assert.Empty(t, files)
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   source
+		name   string
+	}{
+		{"https://github.com/contoso/golden-templates.git", source{kind: sourceGit, location: "https://github.com/contoso/golden-templates.git"}, "golden-templates"},
+		{"https://github.com/contoso/golden-templates#v1.2.0", source{kind: sourceGit, location: "https://github.com/contoso/golden-templates", ref: "v1.2.0"}, "golden-templates"},
+		{"oci://contoso.azurecr.io:5000/draft/Templates:v1", source{kind: sourceOCI, location: "contoso.azurecr.io:5000/draft/Templates", ref: "v1"}, "templates"},
+		{"oci://contoso.azurecr.io/templates@sha256:abc", source{kind: sourceOCI, location: "contoso.azurecr.io/templates", ref: "sha256:abc"}, "templates"},
+		{"oci-layout:///tmp/layout:latest", source{kind: sourceOCILayout, location: "/tmp/layout", ref: "latest"}, "layout"},
+	}
+	for _, tt := range tests {
+		src, err := parseSource(tt.source)
+		assert.Nil(t, err, tt.source)
+		assert.Equal(t, tt.want, src, tt.source)
+		assert.Equal(t, tt.name, src.defaultName(), tt.source)
+	}
+
+	_, err := parseSource("oci-layout:///tmp/layout")
+	assert.NotNil(t, err)
+	_, err = parseSource("")
+	assert.NotNil(t, err)
+}
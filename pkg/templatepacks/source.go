@@ -0,0 +1,239 @@
+package templatepacks
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// PackMediaType is the media type of the layer of an OCI artifact holding a template pack, a gzipped tar archive of the
+// pack directory
+const PackMediaType = "application/vnd.azure.draft.templates.v1.tar+gzip"
+
+const (
+	ociScheme       = "oci://"
+	ociLayoutScheme = "oci-layout://"
+
+	maxManifestBytes = 4 * 1024 * 1024
+	maxPackBytes     = 100 * 1024 * 1024
+)
+
+type sourceKind int
+
+const (
+	sourceGit sourceKind = iota
+	sourceOCI
+	sourceOCILayout
+)
+
+// source is a parsed template pack source
+type source struct {
+	kind     sourceKind
+	location string // the git URL, the registry repository or the OCI layout directory
+	ref      string // the git branch or tag, or the tag or digest of the artifact
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func parseSource(s string) (source, error) {
+	switch {
+	case strings.HasPrefix(s, ociScheme):
+		location, ref := splitOCIReference(strings.TrimPrefix(s, ociScheme))
+		if ref == "" {
+			return source{}, fmt.Errorf("OCI reference %s has no tag or digest", s)
+		}
+		return source{kind: sourceOCI, location: location, ref: ref}, nil
+	case strings.HasPrefix(s, ociLayoutScheme):
+		location, ref := splitOCIReference(strings.TrimPrefix(s, ociLayoutScheme))
+		if ref == "" {
+			return source{}, fmt.Errorf("OCI layout reference %s has no tag or digest", s)
+		}
+		return source{kind: sourceOCILayout, location: location, ref: ref}, nil
+	case s == "":
+		return source{}, errors.New("template pack source is empty")
+	default:
+		location, ref, _ := strings.Cut(s, "#")
+		return source{kind: sourceGit, location: location, ref: ref}, nil
+	}
+}
+
+// splitOCIReference splits a reference like registry/repo:tag or dir@sha256:... into its location and tag or digest
+func splitOCIReference(reference string) (string, string) {
+	if location, digest, ok := strings.Cut(reference, "@"); ok {
+		return location, digest
+	}
+	// a colon before the last path element belongs to the location, like a registry port or a drive letter
+	i := strings.LastIndex(reference, ":")
+	if i < 0 || strings.ContainsAny(reference[i:], `/\`) {
+		return reference, ""
+	}
+	return reference[:i], reference[i+1:]
+}
+
+// defaultName derives a pack name from the last element of the source location
+func (src source) defaultName() string {
+	name := strings.TrimRight(filepath.ToSlash(src.location), "/")
+	name = path.Base(name)
+	name = strings.TrimSuffix(name, ".git")
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(name, "-_")
+}
+
+// fetch writes the pack files of the source to dir
+func (src source) fetch(ctx context.Context, dir string) error {
+	switch src.kind {
+	case sourceOCI:
+		repo, err := remote.NewRepository(src.location)
+		if err != nil {
+			return fmt.Errorf("invalid OCI reference: %w", err)
+		}
+		credStore, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+		if err != nil {
+			return fmt.Errorf("loading registry credentials: %w", err)
+		}
+		repo.Client = &auth.Client{
+			Client:     retry.DefaultClient,
+			Cache:      auth.NewCache(),
+			Credential: credentials.Credential(credStore),
+		}
+		return fetchOCI(ctx, repo, src.ref, dir)
+	case sourceOCILayout:
+		store, err := oci.NewFromFS(ctx, os.DirFS(src.location))
+		if err != nil {
+			return fmt.Errorf("reading OCI layout %s: %w", src.location, err)
+		}
+		return fetchOCI(ctx, store, src.ref, dir)
+	default:
+		return fetchGit(ctx, src.location, src.ref, dir)
+	}
+}
+
+// fetchGit shallow clones a git repository into dir and strips its git metadata
+func fetchGit(ctx context.Context, url, ref, dir string) error {
+	args := []string{"clone", "--depth", "1", "--quiet"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", url, dir)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// fail instead of prompting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+// fetchOCI extracts the pack layer of the artifact tagged or digested ref in target into dir. The fetched content is
+// verified against its digest.
+func fetchOCI(ctx context.Context, target oras.ReadOnlyTarget, ref, dir string) error {
+	_, manifestContent, err := oras.FetchBytes(ctx, target, ref, oras.FetchBytesOptions{MaxBytes: maxManifestBytes})
+	if err != nil {
+		return fmt.Errorf("fetching manifest %s: %w", ref, err)
+	}
+	var manifest ocispec.Manifest
+	if err = json.Unmarshal(manifestContent, &manifest); err != nil {
+		return fmt.Errorf("parsing manifest %s: %w", ref, err)
+	}
+
+	var layer *ocispec.Descriptor
+	for i, l := range manifest.Layers {
+		if l.MediaType == PackMediaType {
+			if layer != nil {
+				return fmt.Errorf("artifact %s has more than one %s layer", ref, PackMediaType)
+			}
+			layer = &manifest.Layers[i]
+		}
+	}
+	if layer == nil {
+		return fmt.Errorf("artifact %s has no %s layer", ref, PackMediaType)
+	}
+	if layer.Size > maxPackBytes {
+		return fmt.Errorf("template pack layer of %s exceeds %d bytes", ref, maxPackBytes)
+	}
+
+	archive, err := content.FetchAll(ctx, target, *layer)
+	if err != nil {
+		return fmt.Errorf("fetching template pack layer: %w", err)
+	}
+	return extractTarGz(bytes.NewReader(archive), dir)
+}
+
+// extractTarGz extracts the directories and regular files of a gzipped tar archive into dir, rejecting entries that
+// would be written outside of it
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading template pack archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading template pack archive: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." && header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("template pack archive entry %s is outside of the pack", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("extracting %s: %w", header.Name, err)
+			}
+		case tar.TypeXGlobalHeader:
+		default:
+			return fmt.Errorf("template pack archive entry %s is not a regular file or directory", header.Name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package writers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Azure/draft/pkg/merge"
+	"github.com/Azure/draft/pkg/osutil"
+)
+
+// GeneratedStateDir is the directory, relative to the project root, where the last generated contents of every file are recorded
+const GeneratedStateDir = ".draft/generated"
+
+type MergeStrategy string
+
+const (
+	// MergeStrategyNone overwrites existing files with the newly generated contents without recording state
+	MergeStrategyNone MergeStrategy = ""
+	// MergeStrategyMarkers merges local edits and writes conflict markers for colliding hunks
+	MergeStrategyMarkers MergeStrategy = "markers"
+	// MergeStrategyRefuse merges local edits and refuses to write files with colliding hunks
+	MergeStrategyRefuse MergeStrategy = "refuse"
+)
+
+var ErrMergeConflict = errors.New("merge conflict")
+
+// MergeWriter three-way merges generated files with the files on disk, between the last generated contents recorded
+// under GeneratedStateDir, the current contents on disk and the newly generated contents. Files are only staged by
+// WriteFile and written, along with their generated state, by Commit, so a refused merge leaves the tree untouched.
+// Existing files without recorded state are kept as they are, recording the generated contents to merge against next time.
+type MergeWriter struct {
+	Root      string
+	Strategy  MergeStrategy
+	WriteMode os.FileMode
+
+	// Conflicts lists the files with colliding hunks, written with conflict markers unless the strategy refuses them
+	Conflicts []string
+
+	staged []stagedFile
+}
+
+type stagedFile struct {
+	path      string
+	statePath string
+	content   []byte // nil when the current file is kept
+	generated []byte
+}
+
+func (w *MergeWriter) WriteFile(path string, data []byte) error {
+	statePath, err := w.statePath(path)
+	if err != nil {
+		return err
+	}
+
+	content, err := w.merge(path, statePath, data)
+	if err != nil {
+		return err
+	}
+	w.staged = append(w.staged, stagedFile{path: path, statePath: statePath, content: content, generated: data})
+	return nil
+}
+
+func (w *MergeWriter) EnsureDirectory(path string) error {
+	// directories are created by Commit along with the files written to them
+	return nil
+}
+
+// Commit writes the staged files and their generated state. Nothing is written if the strategy refuses conflicts and
+// any staged file has one.
+func (w *MergeWriter) Commit() error {
+	if w.Strategy == MergeStrategyRefuse && len(w.Conflicts) > 0 {
+		return fmt.Errorf("%w: conflicting hunks in %s, no files were written", ErrMergeConflict, strings.Join(w.Conflicts, ", "))
+	}
+
+	for _, f := range w.staged {
+		if f.content != nil {
+			if err := osutil.EnsureDirectory(filepath.Dir(f.path)); err != nil {
+				return err
+			}
+			if err := os.WriteFile(f.path, f.content, w.writeMode()); err != nil {
+				return err
+			}
+		}
+		if err := osutil.EnsureDirectory(filepath.Dir(f.statePath)); err != nil {
+			return fmt.Errorf("creating generated state directory: %w", err)
+		}
+		if err := os.WriteFile(f.statePath, f.generated, 0644); err != nil {
+			return err
+		}
+	}
+	w.staged = nil
+	return nil
+}
+
+// merge returns the contents to write to path, or nil to keep the current file
+func (w *MergeWriter) merge(path, statePath string, data []byte) ([]byte, error) {
+	current, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading current file %s: %w", path, err)
+	}
+
+	if bytes.Equal(current, data) {
+		return data, nil
+	}
+
+	base, err := os.ReadFile(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("no generated state recorded for %s, keeping the current file; later merges will apply regenerated changes to it", path)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading last generated file %s: %w", statePath, err)
+	}
+
+	merged, conflicts := merge.ThreeWay(base, current, data)
+	if conflicts > 0 {
+		w.Conflicts = append(w.Conflicts, path)
+		if w.Strategy == MergeStrategyRefuse {
+			log.Errorf("%d conflicting hunk(s) in %s", conflicts, path)
+			return nil, nil
+		}
+		log.Warnf("%d conflicting hunk(s) in %s, resolve the conflict markers before committing", conflicts, path)
+	}
+
+	return merged, nil
+}
+
+func (w *MergeWriter) statePath(path string) (string, error) {
+	root, err := filepath.Abs(w.Root)
+	if err != nil {
+		return "", fmt.Errorf("resolving project root: %w", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving file path: %w", err)
+	}
+
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("file %s is outside of project root %s", path, w.Root)
+	}
+
+	return filepath.Join(root, GeneratedStateDir, relPath), nil
+}
+
+func (w *MergeWriter) writeMode() os.FileMode {
+	if w.WriteMode == 0 {
+		return 0644
+	}
+	return w.WriteMode
+}
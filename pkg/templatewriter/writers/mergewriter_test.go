@@ -0,0 +1,77 @@
+package writers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeWriter(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, "manifests", "deployment.yaml")
+	statePath := filepath.Join(root, GeneratedStateDir, "manifests", "deployment.yaml")
+
+	w := &MergeWriter{Root: root, Strategy: MergeStrategyMarkers}
+	assert.Nil(t, w.EnsureDirectory(filepath.Dir(filePath)))
+	assert.Nil(t, w.WriteFile(filePath, []byte("replicas: 1\nport: 80\nimage: app:v1\n")))
+	assert.NoFileExists(t, filePath, "files are only written on commit")
+	assert.Nil(t, w.Commit())
+
+	state, err := os.ReadFile(statePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 1\nport: 80\nimage: app:v1\n", string(state))
+
+	// local edit is preserved while the regenerated change is applied
+	assert.Nil(t, os.WriteFile(filePath, []byte("replicas: 3\nport: 80\nimage: app:v1\n"), 0644))
+	w = &MergeWriter{Root: root, Strategy: MergeStrategyMarkers}
+	assert.Nil(t, w.WriteFile(filePath, []byte("replicas: 1\nport: 80\nimage: app:v2\n")))
+	assert.Nil(t, w.Commit())
+	merged, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 3\nport: 80\nimage: app:v2\n", string(merged))
+	assert.Empty(t, w.Conflicts)
+
+	// colliding hunks are refused without writing any file, including the ones staged before
+	otherPath := filepath.Join(root, "manifests", "service.yaml")
+	w = &MergeWriter{Root: root, Strategy: MergeStrategyRefuse}
+	assert.Nil(t, w.WriteFile(otherPath, []byte("port: 80\n")))
+	assert.Nil(t, w.WriteFile(filePath, []byte("replicas: 2\nport: 80\nimage: app:v2\n")))
+	err = w.Commit()
+	assert.True(t, errors.Is(err, ErrMergeConflict))
+	assert.NoFileExists(t, otherPath)
+	unchanged, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "replicas: 3\nport: 80\nimage: app:v2\n", string(unchanged))
+
+	// or written with conflict markers
+	w = &MergeWriter{Root: root, Strategy: MergeStrategyMarkers}
+	assert.Nil(t, w.WriteFile(filePath, []byte("replicas: 2\nport: 80\nimage: app:v2\n")))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, []string{filePath}, w.Conflicts)
+	conflicted, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(conflicted), "<<<<<<< current\nreplicas: 3\n")
+
+	assert.NotNil(t, w.WriteFile(filepath.Join(root, "..", "outside.yaml"), []byte("a")))
+}
+
+func TestMergeWriterWithoutState(t *testing.T) {
+	root := t.TempDir()
+	filePath := filepath.Join(root, "Dockerfile")
+	assert.Nil(t, os.WriteFile(filePath, []byte("FROM edited\n"), 0644))
+
+	// a file generated without recorded state is kept, and the generated contents become the base of the next merge
+	w := &MergeWriter{Root: root, Strategy: MergeStrategyMarkers}
+	assert.Nil(t, w.WriteFile(filePath, []byte("FROM generated\n")))
+	assert.Nil(t, w.Commit())
+	assert.Empty(t, w.Conflicts)
+	kept, err := os.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "FROM edited\n", string(kept))
+	state, err := os.ReadFile(filepath.Join(root, GeneratedStateDir, "Dockerfile"))
+	assert.Nil(t, err)
+	assert.Equal(t, "FROM generated\n", string(state))
+}